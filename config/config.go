@@ -3,11 +3,14 @@ package config
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/BurntSushi/toml"
 	"github.com/gocarina/gocsv"
@@ -59,42 +62,30 @@ func AddConfigPath(in string) {
 
 // Initialize your config
 func Initialize(configFile string, cfgStructPtr interface{}) error {
-	if err := set(configFile, cfgStructPtr); err != nil {
-		return err
-	}
-
-	if err := configer.loadConfig(); err != nil {
-		return err
-	}
-
-	if err := cputil.DeepCopy(cfgStructPtr, configer.container); err != nil {
-		return err
-	}
-	return nil
+	return configer.Initialize(configFile, cfgStructPtr)
 }
 
-func set(configFile string, cfgStructPtr interface{}) error {
-	configName, configType := fileInfo(configFile)
-	configer.configFile = ""
-	configer.configName = configName
-
-	if stringInSlice(configType, SupportedExts) {
-		configer.configType = configType
-	} else {
-		return InvalidConfigTypeError(configType)
-	}
+// Get return your config.
+// The returned value is a shared read-only snapshot, it is swapped atomically
+// on reload and must not be modified, use Snapshot to get a private copy.
+func Get() interface{} {
+	return configer.Get()
+}
 
-	if err := checkObject(cfgStructPtr); err != nil {
-		return err
-	}
+// Snapshot deep copy current config into cfgStructPtr
+func Snapshot(cfgStructPtr interface{}) error {
+	return configer.Snapshot(cfgStructPtr)
+}
 
-	configer.container = cfgStructPtr
-	return nil
+// Reload reload config from the last source, then swap it atomically,
+// see Configer.Reload
+func Reload() error {
+	return configer.Reload()
 }
 
-// Get return your config
-func Get() interface{} {
-	return configer.container
+// Freeze prevents any further Initialize/Reload, usually called after boot
+func Freeze() {
+	configer.Freeze()
 }
 
 // Reset Intended for testing, will reset all to default settings.
@@ -119,9 +110,30 @@ func New() *Configer {
 	}
 }
 
+// ErrFrozen is returned when loading config after Freeze
+var ErrFrozen = errors.New("config is frozen")
+
+// ErrNotInitialized is returned when reading config before Initialize
+var ErrNotInitialized = errors.New("config is not initialized")
+
+// snapshot wraps loaded config, atomic.Value requires a consistent concrete type
+type snapshot struct {
+	value interface{}
+}
+
 // Configer config manager
 type Configer struct {
+	// mu serializes loading, readers only go through current
+	mu      sync.Mutex
+	current atomic.Value
+	frozen  int32
+
+	// container is the config being loaded, guarded by mu
 	container interface{}
+	// configData is the config content when loaded from reader/bytes/URL
+	configData []byte
+	// fetch re-fetches the URL source on Reload, nil for other sources
+	fetch func() ([]byte, error)
 
 	configPaths []string
 	configName  string
@@ -136,13 +148,154 @@ type Configer struct {
 
 // AddPath into lookup path list
 func (c *Configer) AddPath(in string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	in = absPath(in)
 	if in != "" {
 		c.configPaths = append(c.configPaths, in)
 	}
 }
 
+// Initialize load config file, then swap it atomically
+func (c *Configer) Initialize(configFile string, cfgStructPtr interface{}) error {
+	return c.initialize(cfgStructPtr, func() error {
+		if err := c.set(configFile, cfgStructPtr); err != nil {
+			return err
+		}
+		return c.loadConfig()
+	})
+}
+
+// InitializeFromBytes load config from data, then swap it atomically
+func (c *Configer) InitializeFromBytes(data []byte, configType string, cfgStructPtr interface{}) error {
+	return c.initialize(cfgStructPtr, func() error {
+		if err := c.setType(configType, cfgStructPtr); err != nil {
+			return err
+		}
+		c.configData, c.fetch = data, nil
+		return c.loadConfig()
+	})
+}
+
+// initializeFromFetch load config from the content returned by fetch, fetch is called again on Reload
+func (c *Configer) initializeFromFetch(fetch func() ([]byte, error), configType string, cfgStructPtr interface{}) error {
+	return c.initialize(cfgStructPtr, func() error {
+		if err := c.setType(configType, cfgStructPtr); err != nil {
+			return err
+		}
+		data, err := fetch()
+		if err != nil {
+			return err
+		}
+		c.configData, c.fetch = data, fetch
+		return c.loadConfig()
+	})
+}
+
+// Reload reload config from the last source, then swap it atomically:
+// config files and URLs are read again, content loaded from reader/bytes is replayed
+// from the cached copy. Tag local files and env are applied again in all cases.
+func (c *Configer) Reload() error {
+	cur := c.Get()
+	if cur == nil {
+		return ErrNotInitialized
+	}
+
+	return c.initialize(nil, func() error {
+		if c.fetch != nil {
+			data, err := c.fetch()
+			if err != nil {
+				return err
+			}
+			c.configData = data
+		}
+		c.container = cur
+		return c.loadConfig()
+	})
+}
+
+func (c *Configer) initialize(cfgStructPtr interface{}, load func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.IsFrozen() {
+		return ErrFrozen
+	}
+
+	if err := load(); err != nil {
+		return err
+	}
+
+	// container is never modified after stored
+	c.current.Store(&snapshot{value: c.container})
+
+	if cfgStructPtr == nil {
+		return nil
+	}
+	return cputil.DeepCopy(cfgStructPtr, c.container)
+}
+
+func (c *Configer) set(configFile string, cfgStructPtr interface{}) error {
+	configName, configType := fileInfo(configFile)
+	c.configFile = ""
+	c.configName = configName
+	c.configData, c.fetch = nil, nil
+
+	return c.setType(configType, cfgStructPtr)
+}
+
+func (c *Configer) setType(configType string, cfgStructPtr interface{}) error {
+	if stringInSlice(configType, SupportedExts) {
+		c.configType = configType
+	} else {
+		return InvalidConfigTypeError(configType)
+	}
+
+	if err := checkObject(cfgStructPtr); err != nil {
+		return err
+	}
+
+	c.container = cfgStructPtr
+	return nil
+}
+
+// Get return current config snapshot, nil if not initialized.
+// The snapshot is shared and read-only, Reload swaps in a new one instead of changing it,
+// use Snapshot to get a private copy to modify.
+func (c *Configer) Get() interface{} {
+	if s, ok := c.current.Load().(*snapshot); ok {
+		return s.value
+	}
+	return nil
+}
+
+// Snapshot deep copy current config into cfgStructPtr
+func (c *Configer) Snapshot(cfgStructPtr interface{}) error {
+	cur := c.Get()
+	if cur == nil {
+		return ErrNotInitialized
+	}
+	if err := checkObject(cfgStructPtr); err != nil {
+		return err
+	}
+
+	return cputil.DeepCopy(cfgStructPtr, cur)
+}
+
+// Freeze prevents any further Initialize/Reload
+func (c *Configer) Freeze() {
+	atomic.StoreInt32(&c.frozen, 1)
+}
+
+// IsFrozen report whether Freeze is called
+func (c *Configer) IsFrozen() bool {
+	return atomic.LoadInt32(&c.frozen) == 1
+}
+
 func (c *Configer) SetDebug(debug bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.debug = debug
 }
 
@@ -205,10 +358,23 @@ func (c *Configer) searchFile(file string) (string, error) {
 }
 
 func (c *Configer) loadConfig() error {
+	if c.configData != nil {
+		// 加载 reader/bytes 传入的入口配置
+		if err := c.processConfigData(c.configData); err != nil {
+			return err
+		}
+		return c.processOverrides()
+	}
+
 	// 加载核心入口本地配置文件
 	if err := c.processConfigFile(); err != nil {
 		return err
 	}
+	return c.processOverrides()
+}
+
+// processOverrides 加载 tag 本地文件和 Env, 覆盖入口配置
+func (c *Configer) processOverrides() error {
 	// 加载配置 tag 涉及的本地配置文件
 	if err := c.processTagLocalFile(); err != nil {
 		return err
@@ -236,7 +402,11 @@ func (c *Configer) processConfigFile() error {
 		return err
 	}
 
-	cfg, err := c.unmarshalReader(bytes.NewReader(file))
+	return c.processConfigData(file)
+}
+
+func (c *Configer) processConfigData(data []byte) error {
+	cfg, err := c.unmarshalReader(bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"os"
+	"sync"
+
+	"github.com/zhaolion/gostack/util/log"
+)
 
 func (suite *Suite) TestJSON() {
 	cfg := &AppConfig{}
@@ -63,3 +68,72 @@ func (suite *Suite) TestCSV() {
 	suite.Equal("test-app", got.AppName)
 	suite.NotEmpty(got.LessonExtensions)
 }
+
+func (suite *Suite) TestReload() {
+	cfg := &AppConfig{}
+	suite.Require().NoError(Initialize(suite.EnvFile, cfg))
+	first := Get().(*AppConfig)
+	suite.Equal("test", first.AppEnv)
+
+	suite.T().Setenv("APP_ENV", "staging")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				got, ok := Get().(*AppConfig)
+				suite.True(ok)
+				suite.Equal("test-app", got.AppName)
+
+				var snap AppConfig
+				suite.NoError(Snapshot(&snap))
+				suite.Equal("test-app", snap.AppName)
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		suite.NoError(Reload())
+	}
+	wg.Wait()
+
+	suite.Equal("staging", Get().(*AppConfig).AppEnv)
+	suite.Equal("test", first.AppEnv, "old snapshot is never modified")
+}
+
+func (suite *Suite) TestFreeze() {
+	defer Reset()
+
+	Reset()
+	suite.Equal(ErrNotInitialized, Reload())
+	suite.Equal(ErrNotInitialized, Snapshot(&AppConfig{}))
+
+	suite.Require().NoError(Initialize(suite.JSONFile, &AppConfig{}))
+	Freeze()
+	suite.Equal(ErrFrozen, Initialize(suite.JSONFile, &AppConfig{}))
+	suite.Equal(ErrFrozen, Reload())
+
+	var snap AppConfig
+	suite.NoError(Snapshot(&snap))
+	suite.Equal("test-app", snap.AppName)
+}
+
+func (suite *Suite) TestLogConfig() {
+	type ServiceConfig struct {
+		AppName string     `yaml:"app_name"`
+		Log     log.Config `yaml:"log"`
+	}
+
+	suite.T().Setenv("LOG_LEVEL", "error")
+
+	cfg := &ServiceConfig{}
+	data := "app_name: test-app\nlog:\n  level: warn\n  format: logfmt\n  field_map:\n    msg: message\n"
+	suite.Require().NoError(InitializeFromBytes([]byte(data), "yaml", cfg))
+	suite.Equal("error", cfg.Log.Level)
+	suite.Equal("logfmt", cfg.Log.Format)
+	suite.Equal(map[string]string{"msg": "message"}, cfg.Log.FieldMap)
+
+	logger := log.New()
+	suite.Require().NoError(cfg.Log.ApplyTo(logger))
+	suite.Equal(log.ErrorLevel, logger.GetLevel())
+}
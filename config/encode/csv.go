@@ -0,0 +1,278 @@
+package encode
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gocarina/gocsv"
+	"github.com/zhaolion/gostack/util/xerr"
+)
+
+// CSVOptions CSV/TSV tag 文件的解析选项, 类似 `file:"rows.tsv,delim=\t,noheader,comment=#"`
+//
+//	delim=X     列分隔符, 支持单个字符或者 tab/comma/semicolon/pipe/space, .tsv 默认为 tab
+//	comment=X   以 X 开头的行作为注释忽略
+//	noheader    没有表头, 按 struct 字段顺序对应列
+//	lazyquotes  宽松处理引号 (Excel 导出的文件常见)
+type CSVOptions struct {
+	Delim      rune
+	Comment    rune
+	NoHeader   bool
+	LazyQuotes bool
+}
+
+var namedDelims = map[string]rune{
+	"tab":       '\t',
+	"comma":     ',',
+	"semicolon": ';',
+	"pipe":      '|',
+	"space":     ' ',
+}
+
+// ParseCSVOptions parse tag options for CSV/TSV file
+func ParseCSVOptions(name string, opts []string) (CSVOptions, error) {
+	o := CSVOptions{Delim: ','}
+	if isTSV(name) {
+		o.Delim = '\t'
+	}
+
+	for _, opt := range opts {
+		key, value := opt, ""
+		if i := strings.Index(opt, "="); i >= 0 {
+			key, value = opt[:i], opt[i+1:]
+		}
+
+		switch strings.TrimSpace(key) {
+		case "delim":
+			r, err := parseRune(value)
+			if err != nil {
+				return o, fmt.Errorf("invalid csv delim %q: %w", value, err)
+			}
+			o.Delim = r
+		case "comment":
+			r, err := parseRune(value)
+			if err != nil {
+				return o, fmt.Errorf("invalid csv comment %q: %w", value, err)
+			}
+			o.Comment = r
+		case "noheader":
+			o.NoHeader = true
+		case "lazyquotes":
+			o.LazyQuotes = true
+		case "":
+		default:
+			return o, fmt.Errorf("unknown csv option %q", opt)
+		}
+	}
+
+	return o, nil
+}
+
+func parseRune(value string) (rune, error) {
+	if r, ok := namedDelims[value]; ok {
+		return r, nil
+	}
+	// 允许转义写法, 如 `\t`
+	if strings.HasPrefix(value, `\`) {
+		if s, err := strconv.Unquote(`"` + value + `"`); err == nil {
+			value = s
+		}
+	}
+	if utf8.RuneCountInString(value) != 1 {
+		return 0, fmt.Errorf("expect a single character")
+	}
+	r, _ := utf8.DecodeRuneInString(value)
+	return r, nil
+}
+
+// CSVRowError 单行 CSV 解析错误, Line 为文件中的真实行号
+type CSVRowError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *CSVRowError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *CSVRowError) Unwrap() error {
+	return e.Err
+}
+
+// CSVError 汇总 CSV 文件中所有出错的行
+type CSVError struct {
+	Name string
+	Rows []*CSVRowError
+}
+
+func (e *CSVError) Error() string {
+	lines := make([]string, 0, len(e.Rows)+1)
+	lines = append(lines, fmt.Sprintf("parse %s: %d invalid row(s)", e.Name, len(e.Rows)))
+	for _, row := range e.Rows {
+		lines = append(lines, "\t"+row.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// UnmarshalCSV decode CSV/TSV data into v (slice or array of struct).
+// Every row is decoded on its own, so all invalid rows are reported
+// at once with their line numbers.
+func UnmarshalCSV(name string, data []byte, opts CSVOptions, v reflect.Value) error {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = opts.Delim
+	reader.Comment = opts.Comment
+	reader.LazyQuotes = opts.LazyQuotes
+	// 表格导出的文件经常列数不齐, 交给逐行解析处理
+	reader.FieldsPerRecord = -1
+
+	var (
+		rows   [][]string
+		lines  []int
+		csvErr = &CSVError{Name: name}
+	)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		// 引号等格式错误同样按行收集, 表头出错时无法继续
+		if pe, ok := err.(*csv.ParseError); ok {
+			csvErr.Rows = append(csvErr.Rows, rowError(pe.Line, pe))
+			if !opts.NoHeader && len(rows) == 0 {
+				return xerr.WithStack(csvErr)
+			}
+			continue
+		}
+		if err != nil {
+			return xerr.WithStack(err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, row)
+		lines = append(lines, line)
+	}
+	if len(rows) == 0 && len(csvErr.Rows) == 0 {
+		return xerr.WithStack(gocsv.ErrEmptyCSVFile)
+	}
+
+	var header []string
+	if !opts.NoHeader {
+		header, rows, lines = rows[0], rows[1:], lines[1:]
+	}
+
+	sliceTyp := reflect.SliceOf(v.Type().Elem())
+	out := reflect.MakeSlice(sliceTyp, 0, len(rows))
+	for i, row := range rows {
+		elem, err := unmarshalCSVRow(sliceTyp, header, row)
+		if err != nil {
+			csvErr.Rows = append(csvErr.Rows, rowError(lines[i], err))
+			continue
+		}
+		out = reflect.Append(out, elem)
+	}
+	if len(csvErr.Rows) != 0 {
+		sort.SliceStable(csvErr.Rows, func(i, j int) bool { return csvErr.Rows[i].Line < csvErr.Rows[j].Line })
+		return xerr.WithStack(csvErr)
+	}
+
+	if v.Kind() == reflect.Array {
+		if v.Len() < out.Len() {
+			return xerr.Errorf("parse %s: %d rows overflow array length %d", name, out.Len(), v.Len())
+		}
+		// 清掉上次加载留下的元素
+		v.Set(reflect.Zero(v.Type()))
+		reflect.Copy(v, out)
+		return nil
+	}
+	v.Set(out)
+	return nil
+}
+
+// MarshalCSV is the reverse of UnmarshalCSV, v (slice or array of struct) is
+// written with the delimiter and header options, so UnmarshalCSV reads it back
+func MarshalCSV(v reflect.Value, opts CSVOptions) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	writer := csv.NewWriter(buf)
+	writer.Comma = opts.Delim
+
+	out := gocsv.NewSafeCSVWriter(writer)
+	var err error
+	if opts.NoHeader {
+		err = gocsv.MarshalCSVWithoutHeaders(v.Interface(), out)
+	} else {
+		err = gocsv.MarshalCSV(v.Interface(), out)
+	}
+	if err != nil {
+		return nil, xerr.WithStack(err)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, xerr.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalCSVRow decode a single row with gocsv
+func unmarshalCSVRow(sliceTyp reflect.Type, header, row []string) (elem reflect.Value, err error) {
+	defer func() {
+		// gocsv 在无表头且列数多于字段数时会 panic
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	out := reflect.New(sliceTyp)
+	if header == nil {
+		err = gocsv.UnmarshalCSVWithoutHeaders(&rowsReader{rows: [][]string{row}}, out.Interface())
+	} else {
+		err = gocsv.UnmarshalDecoder(&rowsReader{rows: [][]string{header, row}}, out.Interface())
+	}
+	if err != nil {
+		return elem, err
+	}
+	if out.Elem().Len() == 0 {
+		return elem, fmt.Errorf("empty row")
+	}
+	return out.Elem().Index(0), nil
+}
+
+func rowError(line int, err error) *CSVRowError {
+	if pe, ok := err.(*csv.ParseError); ok {
+		return &CSVRowError{Line: line, Column: pe.Column, Err: pe.Err}
+	}
+	return &CSVRowError{Line: line, Err: err}
+}
+
+// rowsReader feeds already read rows to gocsv
+type rowsReader struct {
+	rows [][]string
+}
+
+func (r *rowsReader) Read() ([]string, error) {
+	if len(r.rows) == 0 {
+		return nil, io.EOF
+	}
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+	return row, nil
+}
+
+func (r *rowsReader) ReadAll() ([][]string, error) {
+	rows := r.rows
+	r.rows = nil
+	return rows, nil
+}
+
+func (r *rowsReader) GetCSVRows() ([][]string, error) {
+	return r.ReadAll()
+}
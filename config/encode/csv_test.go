@@ -0,0 +1,114 @@
+package encode
+
+import (
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Row struct {
+	ID    string `csv:"id"`
+	Count int    `csv:"count"`
+}
+
+func TestParseCSVOptions(t *testing.T) {
+	opts, err := ParseCSVOptions("rows.csv", nil)
+	require.NoError(t, err)
+	assert.Equal(t, CSVOptions{Delim: ','}, opts)
+
+	opts, err = ParseCSVOptions("rows.tsv", nil)
+	require.NoError(t, err)
+	assert.Equal(t, '\t', opts.Delim)
+
+	opts, err = ParseCSVOptions("rows.csv", []string{`delim=\t`, "noheader", "comment=#", "lazyquotes"})
+	require.NoError(t, err)
+	assert.Equal(t, CSVOptions{Delim: '\t', Comment: '#', NoHeader: true, LazyQuotes: true}, opts)
+
+	opts, err = ParseCSVOptions("rows.csv", []string{"delim=semicolon"})
+	require.NoError(t, err)
+	assert.Equal(t, ';', opts.Delim)
+
+	_, err = ParseCSVOptions("rows.csv", []string{"delim=ab"})
+	assert.Error(t, err)
+	_, err = ParseCSVOptions("rows.csv", []string{"unknown"})
+	assert.Error(t, err)
+}
+
+func TestEncodeCSVOptions(t *testing.T) {
+	type struct4 struct {
+		TSV      []Row  `file:"rows.tsv"`
+		NoHeader []Row  `file:"noheader.csv,delim=|,noheader,comment=#"`
+		Array    [2]Row `file:"array.csv"`
+	}
+	files := map[string]string{
+		"rows.tsv":     "id\tcount\na\t1\nb\t2\n",
+		"noheader.csv": "# exported at 2022-08-01\nc|3\n\nd|4\n",
+		"array.csv":    "id,count\ne,5\n",
+	}
+	getFile := func(key string) (string, error) {
+		return files[key], nil
+	}
+
+	var s struct4
+	require.NoError(t, Encode(&s, "file", getFile))
+	assert.Equal(t, []Row{{"a", 1}, {"b", 2}}, s.TSV)
+	assert.Equal(t, []Row{{"c", 3}, {"d", 4}}, s.NoHeader)
+	assert.Equal(t, [2]Row{{"e", 5}}, s.Array)
+}
+
+func TestEncodeCSVRowErrors(t *testing.T) {
+	type struct5 struct {
+		Rows []Row `file:"rows.csv,comment=#"`
+	}
+	data := "id,count\n# comment\na,1\nb,x\nc,3\nd,y\ne\"x,5\nf,6\n"
+	getFile := func(key string) (string, error) {
+		return data, nil
+	}
+
+	var s struct5
+	err := Encode(&s, "file", getFile)
+	require.Error(t, err)
+
+	var csvErr *CSVError
+	require.True(t, errors.As(err, &csvErr))
+	assert.Equal(t, "rows.csv", csvErr.Name)
+	require.Len(t, csvErr.Rows, 3)
+	assert.Equal(t, 4, csvErr.Rows[0].Line)
+	assert.Equal(t, 2, csvErr.Rows[0].Column)
+	assert.Equal(t, 6, csvErr.Rows[1].Line)
+	// 格式错误也按行收集
+	assert.Equal(t, 7, csvErr.Rows[2].Line)
+	assert.ErrorIs(t, csvErr.Rows[2], csv.ErrBareQuote)
+
+	// 表头格式错误
+	data = "id,\"count\n"
+	err = Encode(&struct5{}, "file", getFile)
+	require.True(t, errors.As(err, &csvErr))
+	require.Len(t, csvErr.Rows, 1)
+	assert.ErrorIs(t, csvErr.Rows[0], csv.ErrQuote)
+}
+
+func TestEncodeCSVArray(t *testing.T) {
+	type struct6 struct {
+		Rows [3]Row `file:"rows.csv"`
+	}
+	data := "id,count\na,1\nb,2\nc,3\n"
+	getFile := func(key string) (string, error) {
+		return data, nil
+	}
+
+	var s struct6
+	require.NoError(t, Encode(&s, "file", getFile))
+	assert.Equal(t, Row{ID: "c", Count: 3}, s.Rows[2])
+
+	// 重新加载不保留上次的元素
+	data = "id,count\nd,4\n"
+	require.NoError(t, Encode(&s, "file", getFile))
+	assert.Equal(t, [3]Row{{ID: "d", Count: 4}}, s.Rows)
+
+	data = "id,count\na,1\nb,2\nc,3\nd,4\n"
+	assert.Error(t, Encode(&s, "file", getFile))
+}
@@ -8,7 +8,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/gocarina/gocsv"
 	"github.com/zhaolion/gostack/util/xerr"
 	"gopkg.in/yaml.v2"
 )
@@ -39,7 +38,7 @@ func walkStructValue(v reflect.Value, tag string, fn GetValueFn) (err error) {
 		fVal := reflect.Indirect(v.Field(i))
 
 		// 获取 tag, 类似 `dva:"foo.yaml"`
-		name, _ := parseTag(tagVal)
+		name, opts := parseTag(tagVal)
 
 		switch fVal.Kind() {
 		case reflect.Struct:
@@ -72,16 +71,18 @@ func walkStructValue(v reflect.Value, tag string, fn GetValueFn) (err error) {
 				err = walkStructValue(fVal, tag, fn)
 			}
 		case reflect.Slice, reflect.Array:
-			if isCSV(name) {
-				value, err := fn(name)
+			if isCSV(name) || isTSV(name) {
+				csvOpts, err := ParseCSVOptions(name, opts)
 				if err != nil {
 					return xerr.WithStack(err)
 				}
-				fieldObj := reflect.New(typ.Field(i).Type).Interface()
-				if err := gocsv.UnmarshalBytes([]byte(value), fieldObj); err != nil {
+				value, err := fn(name)
+				if err != nil {
 					return xerr.WithStack(err)
 				}
-				fVal.Set(reflect.Indirect(reflect.ValueOf(fieldObj)))
+				if err := UnmarshalCSV(name, []byte(value), csvOpts, fVal); err != nil {
+					return err
+				}
 
 				continue
 			} else {
@@ -89,12 +90,23 @@ func walkStructValue(v reflect.Value, tag string, fn GetValueFn) (err error) {
 					continue
 				}
 
+				if isStructType(fVal.Type().Elem()) {
+					err = setStructSliceVal(fVal, name, tag, fn)
+					continue
+				}
+
 				var value string
 				value, err = fn(name)
 				if value != "" {
 					err = SetValue(fVal, value)
 				}
 			}
+		case reflect.Map:
+			if name == "" || name == "-" {
+				continue
+			}
+
+			err = setMapVal(fVal, name, tag, fn)
 		default:
 			if name == "" || name == "-" {
 				continue
@@ -178,6 +190,16 @@ func setFloatVal(v reflect.Value, value string) error {
 }
 
 func setSliceVal(v reflect.Value, value string) error {
+	// JSON 数组, 类似 `["a","b"]`, 无法解析时仍然按逗号分隔处理 (如 `[::1]:80`)
+	if strings.HasPrefix(value, "[") && json.Valid([]byte(value)) {
+		nv := reflect.New(v.Type())
+		if err := json.Unmarshal([]byte(value), nv.Interface()); err != nil {
+			return err
+		}
+		v.Set(nv.Elem())
+		return nil
+	}
+
 	vv := strings.Split(value, ",")
 	var nv reflect.Value
 	if v.Kind() == reflect.Array {
@@ -209,3 +231,7 @@ func isYaml(key string) bool {
 func isCSV(key string) bool {
 	return strings.HasSuffix(key, ".csv") || strings.HasSuffix(key, ".csv.secret")
 }
+
+func isTSV(key string) bool {
+	return strings.HasSuffix(key, ".tsv") || strings.HasSuffix(key, ".tsv.secret")
+}
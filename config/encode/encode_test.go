@@ -126,6 +126,60 @@ func TestEncodeDVA(t *testing.T) {
 	}
 }
 
+func TestEncodeNested(t *testing.T) {
+	type Server struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+	type struct3 struct {
+		Servers   []Server          `env:"SERVERS"`
+		Backups   []*Server         `env:"BACKUPS"`
+		Upstreams map[string]Server `env:"UPSTREAMS"`
+		Weights   map[string]int    `env:"WEIGHTS"`
+		Limits    map[string]*int   `env:"LIMITS"`
+		Tags      []string          `env:"TAGS"`
+	}
+	envs := map[string]string{
+		"SERVERS":                `[{"Host":"a.local","Port":80},{"Host":"b.local","Port":81}]`,
+		"SERVERS_1_PORT":         "8081",
+		"SERVERS_2_HOST":         "c.local",
+		"SERVERS_4_HOST":         "e.local",
+		"BACKUPS_0_HOST":         "backup.local",
+		"UPSTREAMS":              `{"payment":{"Host":"pay.local","Port":80}}`,
+		"UPSTREAMS_payment_HOST": "pay.internal",
+		"WEIGHTS":                `{"a":1,"b":2}`,
+		"WEIGHTS_b":              "20",
+		"LIMITS":                 `{"a":null,"b":1}`,
+		"LIMITS_a":               "5",
+		"TAGS":                   `["x","y"]`,
+	}
+	getValue := func(key string) (string, error) {
+		return envs[key], nil
+	}
+
+	var s struct3
+	if err := Encode(&s, "env", getValue); err != nil {
+		t.Fatal(err)
+	}
+	limitA, limitB := 5, 1
+	// SERVERS_4_HOST 不连续, 被忽略
+	value := struct3{
+		Servers:   []Server{{"a.local", 80}, {"b.local", 8081}, {"c.local", 0}},
+		Backups:   []*Server{{"backup.local", 0}},
+		Upstreams: map[string]Server{"payment": {"pay.internal", 80}},
+		Weights:   map[string]int{"a": 1, "b": 20},
+		Limits:    map[string]*int{"a": &limitA, "b": &limitB},
+		Tags:      []string{"x", "y"},
+	}
+	eq, err := equal(s, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eq {
+		t.Errorf("\nexpected: %+v\ngot: %+v", value, s)
+	}
+}
+
 func TestSetBoolVal(t *testing.T) {
 	type TC struct {
 		Value  string
@@ -0,0 +1,178 @@
+package encode
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/zhaolion/gostack/util/xerr"
+	"gopkg.in/yaml.v2"
+)
+
+// KeySeparator joins a slice/map field name with the element index/key
+// and the element field name, e.g. SERVERS_0_HOST, UPSTREAMS_payment_URL.
+const KeySeparator = "_"
+
+// setStructSliceVal fills slice/array of structs:
+//  1. `SERVERS` holds the whole list encoded as JSON (YAML when the name ends with .yml/.yaml)
+//  2. `SERVERS_<index>_<FIELD>` overrides a field of the element at index,
+//     indexes right after the last element append new elements to a slice.
+//     appending stops at the first index without any value, e.g. with 2 elements
+//     SERVERS_3_HOST is ignored if no SERVERS_2_<FIELD> is set
+func setStructSliceVal(v reflect.Value, name, tag string, fn GetValueFn) error {
+	value, err := fn(name)
+	if err != nil {
+		return xerr.WithStack(err)
+	}
+	if value != "" {
+		if err := unmarshalValue(name, value, v); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		elem := reflect.Indirect(v.Index(i))
+		if !elem.IsValid() {
+			continue
+		}
+		if _, err := walkElem(elem, tag, elemValueFn(fn, name, i)); err != nil {
+			return err
+		}
+	}
+
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+
+	elemTyp := v.Type().Elem()
+	for i := v.Len(); ; i++ {
+		ptr := reflect.New(indirectType(elemTyp))
+		hit, err := walkElem(ptr.Elem(), tag, elemValueFn(fn, name, i))
+		if err != nil {
+			return err
+		}
+		if !hit {
+			return nil
+		}
+		if elemTyp.Kind() == reflect.Ptr {
+			v.Set(reflect.Append(v, ptr))
+		} else {
+			v.Set(reflect.Append(v, ptr.Elem()))
+		}
+	}
+}
+
+// setMapVal fills map:
+//  1. `UPSTREAMS` holds the whole map encoded as JSON (YAML when the name ends with .yml/.yaml)
+//  2. `UPSTREAMS_<key>_<FIELD>` overrides a field of the struct value at key,
+//     `UPSTREAMS_<key>` overrides a scalar value at key.
+//     only existing keys are overridden, new keys should come from the whole map
+func setMapVal(v reflect.Value, name, tag string, fn GetValueFn) error {
+	value, err := fn(name)
+	if err != nil {
+		return xerr.WithStack(err)
+	}
+	if value != "" {
+		if err := unmarshalValue(name, value, v); err != nil {
+			return err
+		}
+	}
+	if v.IsNil() {
+		return nil
+	}
+
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+
+	elemTyp := v.Type().Elem()
+	for _, key := range keys {
+		keyName := name + KeySeparator + fmt.Sprint(key.Interface())
+
+		// map 元素不可寻址, 复制一份修改后再写回
+		elem := reflect.New(elemTyp).Elem()
+		elem.Set(v.MapIndex(key))
+
+		if isStructType(elemTyp) {
+			target := reflect.Indirect(elem)
+			if !target.IsValid() {
+				continue
+			}
+			hit, err := walkElem(target, tag, prefixValueFn(fn, keyName+KeySeparator))
+			if err != nil {
+				return err
+			}
+			if hit {
+				v.SetMapIndex(key, elem)
+			}
+			continue
+		}
+
+		value, err := fn(keyName)
+		if err != nil {
+			return xerr.WithStack(err)
+		}
+		if value == "" {
+			continue
+		}
+		// nil 指针 (如 yaml 的 key: null) 先分配
+		if elem.Kind() == reflect.Ptr && elem.IsNil() {
+			elem.Set(reflect.New(elemTyp.Elem()))
+		}
+		if err := SetValue(reflect.Indirect(elem), value); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+	}
+
+	return nil
+}
+
+// walkElem walks the struct element and reports whether any value was found
+func walkElem(elem reflect.Value, tag string, fn GetValueFn) (hit bool, err error) {
+	err = walkStructValue(elem, tag, func(key string) (string, error) {
+		value, err := fn(key)
+		if value != "" {
+			hit = true
+		}
+		return value, err
+	})
+	return hit, err
+}
+
+func elemValueFn(fn GetValueFn, name string, index int) GetValueFn {
+	return prefixValueFn(fn, fmt.Sprintf("%s%s%d%s", name, KeySeparator, index, KeySeparator))
+}
+
+func prefixValueFn(fn GetValueFn, prefix string) GetValueFn {
+	return func(key string) (string, error) {
+		return fn(prefix + key)
+	}
+}
+
+func unmarshalValue(name, value string, v reflect.Value) error {
+	obj := reflect.New(v.Type())
+	obj.Elem().Set(v)
+	if isYaml(name) {
+		if err := yaml.Unmarshal([]byte(value), obj.Interface()); err != nil {
+			return xerr.WithStack(err)
+		}
+	} else if err := json.Unmarshal([]byte(value), obj.Interface()); err != nil {
+		return xerr.WithStack(err)
+	}
+	v.Set(obj.Elem())
+	return nil
+}
+
+func isStructType(typ reflect.Type) bool {
+	return indirectType(typ).Kind() == reflect.Struct
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		return typ.Elem()
+	}
+	return typ
+}
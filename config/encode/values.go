@@ -0,0 +1,264 @@
+package encode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zhaolion/gostack/util/xerr"
+	"gopkg.in/yaml.v2"
+)
+
+// Values is the reverse of Encode: it walks a populated struct and returns
+// tag name => string value, formatted so that Encode reads them back.
+//
+//   - scalars use strconv formatting, slices/arrays are joined by comma
+//     (JSON array when an element contains a comma)
+//   - slices of structs are flattened as SERVERS_0_HOST
+//   - maps are JSON objects under the map's own name, e.g. WEIGHTS={"a":1},
+//     keys flattened as WEIGHTS_a only override existing keys when read back
+//   - names ending with .json/.yaml/.csv/.tsv hold the marshaled field,
+//     CSV/TSV fields honor the delim and noheader tag options
+//
+// Empty values are omitted, Encode treats them as unset anyway.
+func Values(i interface{}, tag string) (map[string]string, error) {
+	val := reflect.ValueOf(i)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, errors.New("struct or pointer to struct type required")
+	}
+
+	values := make(map[string]string)
+	if err := collectStructValues(val, tag, "", values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// DotEnv returns values of the struct in .env format, sorted by name
+func DotEnv(i interface{}, tag string) ([]byte, error) {
+	values, err := Values(i, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := WriteDotEnv(buf, values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteDotEnv writes values as KEY=value lines sorted by name,
+// values with spaces, quotes or other special characters are double quoted, see quoteDotEnv.
+func WriteDotEnv(w io.Writer, values map[string]string) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w)
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(bw, "%s=%s\n", k, quoteDotEnv(values[k])); err != nil {
+			return xerr.WithStack(err)
+		}
+	}
+	return xerr.WithStack(bw.Flush())
+}
+
+// quoteDotEnv double quotes value if needed, only \\, \", \n, \r and \t are escaped
+// (non-ASCII text is written as is), $ is escaped as $$ so docker compose doesn't interpolate it
+func quoteDotEnv(value string) string {
+	if value == "" || !strings.ContainsAny(value, " \t\r\n\"'`#$\\=") {
+		return value
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '$':
+			b.WriteString("$$")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func collectStructValues(v reflect.Value, tag, prefix string, values map[string]string) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).PkgPath != "" {
+			continue
+		}
+		tagVal := typ.Field(i).Tag.Get(tag)
+		if tagVal == "-" {
+			continue
+		}
+		fVal := reflect.Indirect(v.Field(i))
+		if !fVal.IsValid() {
+			continue
+		}
+
+		name, opts := parseTag(tagVal)
+		key := prefix + name
+
+		switch fVal.Kind() {
+		case reflect.Struct:
+			if isJson(name) || isYaml(name) {
+				if err := marshalValue(name, key, opts, fVal, values); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := collectStructValues(fVal, tag, prefix, values); err != nil {
+				return err
+			}
+		case reflect.Slice, reflect.Array:
+			if name == "" {
+				continue
+			}
+			if isCSV(name) || isTSV(name) || isJson(name) || isYaml(name) {
+				if err := marshalValue(name, key, opts, fVal, values); err != nil {
+					return err
+				}
+				continue
+			}
+			if isStructType(fVal.Type().Elem()) {
+				for j := 0; j < fVal.Len(); j++ {
+					elem := reflect.Indirect(fVal.Index(j))
+					if !elem.IsValid() {
+						continue
+					}
+					elemPrefix := fmt.Sprintf("%s%s%d%s", key, KeySeparator, j, KeySeparator)
+					if err := collectStructValues(elem, tag, elemPrefix, values); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := setFormatted(key, fVal, values); err != nil {
+				return err
+			}
+		case reflect.Map:
+			if name == "" || fVal.Len() == 0 {
+				continue
+			}
+			// 整个 map 写成 JSON, setMapVal 先按 JSON 解析再处理 NAME_<key>
+			if err := marshalValue(name, key, opts, fVal, values); err != nil {
+				return err
+			}
+		default:
+			if name == "" {
+				continue
+			}
+			if err := setFormatted(key, fVal, values); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func setFormatted(key string, v reflect.Value, values map[string]string) error {
+	value, err := FormatValue(v)
+	if err != nil {
+		return xerr.Wrapf(err, "format %s", key)
+	}
+	if value != "" {
+		values[key] = value
+	}
+	return nil
+}
+
+func marshalValue(name, key string, opts []string, v reflect.Value, values map[string]string) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case isYaml(name):
+		data, err = yaml.Marshal(v.Interface())
+	case isCSV(name) || isTSV(name):
+		var csvOpts CSVOptions
+		if csvOpts, err = ParseCSVOptions(name, opts); err == nil {
+			data, err = MarshalCSV(v, csvOpts)
+		}
+	default:
+		data, err = json.Marshal(v.Interface())
+	}
+	if err != nil {
+		return xerr.Wrapf(err, "marshal %s", key)
+	}
+	values[key] = string(data)
+	return nil
+}
+
+// FormatValue is the reverse of SetValue
+func FormatValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Array, reflect.Slice:
+		return formatSliceVal(v)
+	default:
+		return "", fmt.Errorf("unknown supported type: %s, %s", v.Kind(), v.Type().Name())
+	}
+}
+
+func formatSliceVal(v reflect.Value) (string, error) {
+	vv := make([]string, v.Len())
+	needJSON := false
+	for i := 0; i < v.Len(); i++ {
+		s, err := FormatValue(v.Index(i))
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(s, ",") {
+			needJSON = true
+		}
+		vv[i] = s
+	}
+
+	// 元素带逗号无法按逗号分隔还原, setSliceVal 同样支持 JSON 数组
+	if needJSON {
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return strings.Join(vv, ","), nil
+}
@@ -0,0 +1,160 @@
+package encode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type valuesServer struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT"`
+}
+
+type valuesConfig struct {
+	Name    string  `env:"APP_NAME"`
+	Debug   bool    `env:"APP_DEBUG"`
+	Rate    float64 `env:"APP_RATE"`
+	Omit    string  `env:"-"`
+	Empty   string  `env:"APP_EMPTY"`
+	Hosts   []string
+	Tags    []string                 `env:"APP_TAGS"`
+	Labels  []string                 `env:"APP_LABELS"`
+	Pair    [2]string                `env:"APP_PAIR"`
+	Dummy   Dummy                    `env:"dummy.json"`
+	Servers []valuesServer           `env:"SERVERS"`
+	Weights map[string]int           `env:"WEIGHTS"`
+	Routes  map[string]*valuesServer `env:"ROUTES"`
+	DB      struct {
+		DSN string `env:"DB_DSN"`
+	}
+}
+
+func TestValues(t *testing.T) {
+	cfg := valuesConfig{
+		Name:    "app",
+		Debug:   true,
+		Rate:    0.618,
+		Omit:    "omit",
+		Hosts:   []string{"a"},
+		Tags:    []string{"a", "b"},
+		Labels:  []string{"a,b", "c"},
+		Pair:    [2]string{"a,b", "c"},
+		Dummy:   Dummy{A: "123"},
+		Servers: []valuesServer{{"a.local", 80}, {"b.local", 81}},
+		Weights: map[string]int{"a": 1, "b": 2},
+		Routes:  map[string]*valuesServer{"pay": {"pay.local", 0}},
+	}
+	cfg.DB.DSN = "root@tcp(localhost:3306)/test"
+
+	values, err := Values(&cfg, "env")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"APP_NAME":       "app",
+		"APP_DEBUG":      "true",
+		"APP_RATE":       "0.618",
+		"APP_TAGS":       "a,b",
+		"APP_LABELS":     `["a,b","c"]`,
+		"APP_PAIR":       `["a,b","c"]`,
+		"dummy.json":     `{"a":"123"}`,
+		"SERVERS_0_HOST": "a.local",
+		"SERVERS_0_PORT": "80",
+		"SERVERS_1_HOST": "b.local",
+		"SERVERS_1_PORT": "81",
+		"WEIGHTS":        `{"a":1,"b":2}`,
+		"ROUTES":         `{"pay":{"Host":"pay.local","Port":0}}`,
+		"DB_DSN":         "root@tcp(localhost:3306)/test",
+	}, values)
+
+	// 反向写回
+	var got valuesConfig
+	require.NoError(t, Encode(&got, "env", func(key string) (string, error) {
+		return values[key], nil
+	}))
+	cfg.Omit = ""
+	cfg.Hosts = nil
+	assert.Equal(t, cfg, got)
+}
+
+func TestDotEnv(t *testing.T) {
+	type struct6 struct {
+		Name  string `env:"APP_NAME"`
+		Title string `env:"APP_TITLE"`
+		Port  int    `env:"APP_PORT"`
+	}
+	data, err := DotEnv(struct6{Name: "app", Title: `hello "world"`, Port: 8080}, "env")
+	require.NoError(t, err)
+	assert.Equal(t, "APP_NAME=app\nAPP_PORT=8080\nAPP_TITLE=\"hello \\\"world\\\"\"\n", string(data))
+
+	_, err = DotEnv(0, "env")
+	assert.Error(t, err)
+}
+
+// parseDotEnv parses lines written by WriteDotEnv as docker compose does
+func parseDotEnv(t *testing.T, data []byte) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		i := strings.IndexByte(line, '=')
+		require.True(t, i > 0, line)
+		key, value := line[:i], line[i+1:]
+		if strings.HasPrefix(value, `"`) {
+			require.True(t, len(value) >= 2 && strings.HasSuffix(value, `"`), line)
+			value = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\r`, "\r", `\t`, "\t", "$$", "$").
+				Replace(value[1 : len(value)-1])
+		}
+		values[key] = value
+	}
+	return values
+}
+
+func TestDotEnvRoundTrip(t *testing.T) {
+	type struct7 struct {
+		Password string   `env:"APP_PASSWORD"`
+		Title    string   `env:"APP_TITLE"`
+		Note     string   `env:"APP_NOTE"`
+		Hosts    []string `env:"APP_HOSTS"`
+	}
+	cfg := struct7{
+		Password: `p@$$w0rd$HOME\`,
+		Title:    "café 订单 \"new\"",
+		Note:     "line1\n\tline2 # not a comment",
+		Hosts:    []string{"a:80", "b:81"},
+	}
+	data, err := DotEnv(cfg, "env")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `APP_PASSWORD="p@$$$$w0rd$$HOME\\"`)
+	assert.Contains(t, string(data), `APP_TITLE="café 订单 \"new\""`)
+
+	values := parseDotEnv(t, data)
+	var got struct7
+	require.NoError(t, Encode(&got, "env", func(key string) (string, error) {
+		return values[key], nil
+	}))
+	assert.Equal(t, cfg, got)
+}
+
+func TestValuesCSV(t *testing.T) {
+	type csvConfig struct {
+		Rows []Row `env:"rows.csv"`
+		TSV  []Row `env:"rows.tsv,noheader"`
+		Semi []Row `env:"semi.csv,delim=semicolon,comment=#"`
+	}
+	rows := []Row{{ID: "a", Count: 1}, {ID: "b c", Count: 2}}
+	cfg := csvConfig{Rows: rows, TSV: rows, Semi: rows}
+
+	values, err := Values(&cfg, "env")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"rows.csv": "id,count\na,1\nb c,2\n",
+		"rows.tsv": "a\t1\nb c\t2\n",
+		"semi.csv": "id;count\na;1\nb c;2\n",
+	}, values)
+
+	var got csvConfig
+	require.NoError(t, Encode(&got, "env", func(key string) (string, error) {
+		return values[key], nil
+	}))
+	assert.Equal(t, cfg, got)
+}
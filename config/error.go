@@ -39,3 +39,25 @@ type ParseError struct {
 func (pe ParseError) Error() string {
 	return fmt.Sprintf("While parsing config: %s", pe.err.Error())
 }
+
+// UnsupportedURLError denotes encountering an unsupported
+// configuration URL scheme.
+type UnsupportedURLError string
+
+// Error returns the formatted configuration error.
+func (str UnsupportedURLError) Error() string {
+	return fmt.Sprintf("Unsupported Config URL %q", string(str))
+}
+
+// ChecksumError denotes config content not matching the expected checksum.
+type ChecksumError struct {
+	algo, expected, actual string
+}
+
+// Error returns the formatted configuration error.
+func (e ChecksumError) Error() string {
+	if e.actual == "" {
+		return fmt.Sprintf("Unsupported Checksum Algorithm %q", e.algo)
+	}
+	return fmt.Sprintf("Config Checksum Mismatch: %s expected %q, got %q", e.algo, e.expected, e.actual)
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"bytes"
+
+	"github.com/spf13/afero"
+	"github.com/zhaolion/gostack/util/xerr"
+)
+
+// LoadMessages load localized error messages into catalog, see xerr.Localize
+func LoadMessages(catalog *xerr.Catalog, files ...string) error {
+	return configer.LoadMessages(catalog, files...)
+}
+
+// LoadMessages load localized error messages files (json/yaml/toml) into catalog,
+// files are searched in config paths, each file maps language to messages:
+//
+//	zh:
+//	  ORDER_NOT_FOUND: "订单 {id} 不存在"
+//	en:
+//	  ORDER_NOT_FOUND: "order {id} not found"
+//
+// Messages of the later files override the earlier ones.
+func (c *Configer) LoadMessages(catalog *xerr.Catalog, files ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, file := range files {
+		_, ext := fileInfo(file)
+		if !stringInSlice(ext, SupportedExts) {
+			return UnsupportedConfigError(ext)
+		}
+
+		filename, err := c.searchFile(file)
+		if err != nil {
+			return err
+		}
+		data, err := afero.ReadFile(c.fs, filename)
+		if err != nil {
+			return xerr.WithStack(err)
+		}
+
+		// 复用配置文件的解码
+		decoder := &Configer{configType: ext, container: &map[string]map[string]string{}}
+		messages, err := decoder.unmarshalReader(bytes.NewReader(data))
+		if err != nil {
+			return xerr.Wrapf(err, "load messages %s", filename)
+		}
+		for lang, msgs := range *messages.(*map[string]map[string]string) {
+			catalog.Add(lang, msgs)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"github.com/spf13/afero"
+	"github.com/zhaolion/gostack/util/xerr"
+)
+
+const messagesYAML = `
+en:
+  ORDER_NOT_FOUND: "order {id} not found"
+  PAYMENT_DECLINED: "payment declined"
+zh:
+  ORDER_NOT_FOUND: "订单 {id} 不存在"
+`
+
+const messagesJSON = `{"zh-TW": {"ORDER_NOT_FOUND": "訂單 {id} 不存在"}}`
+
+func (suite *Suite) TestLoadMessages() {
+	c := New()
+	c.fs = afero.NewMemMapFs()
+	suite.Require().NoError(afero.WriteFile(c.fs, "messages.yaml", []byte(messagesYAML), 0644))
+	suite.Require().NoError(afero.WriteFile(c.fs, "messages.json", []byte(messagesJSON), 0644))
+
+	catalog := xerr.NewCatalog("en")
+	suite.Require().NoError(c.LoadMessages(catalog, "messages.yaml", "messages.json"))
+
+	msg, ok := catalog.Render("ORDER_NOT_FOUND", xerr.Params{"id": 42}, "zh-CN")
+	suite.True(ok)
+	suite.Equal("订单 42 不存在", msg)
+	msg, _ = catalog.Render("ORDER_NOT_FOUND", xerr.Params{"id": 42}, "zh-TW")
+	suite.Equal("訂單 42 不存在", msg)
+	msg, _ = catalog.Render("PAYMENT_DECLINED", nil, "zh")
+	suite.Equal("payment declined", msg)
+
+	suite.Error(c.LoadMessages(catalog, "missing.yaml"))
+	suite.IsType(UnsupportedConfigError(""), c.LoadMessages(catalog, "messages.ini"))
+}
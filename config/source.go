@@ -0,0 +1,195 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/zhaolion/gostack/util/xerr"
+)
+
+// DefaultURLTimeout timeout for loading config from URL
+var DefaultURLTimeout = 10 * time.Second
+
+// InitializeFromReader init your config from reader, configType is one of SupportedExts.
+// tag local files and env are still applied after the reader content, same as Initialize.
+func InitializeFromReader(in io.Reader, configType string, cfgStructPtr interface{}) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return xerr.WithStack(err)
+	}
+
+	return InitializeFromBytes(data, configType, cfgStructPtr)
+}
+
+// InitializeFromBytes init your config from bytes, configType is one of SupportedExts.
+func InitializeFromBytes(data []byte, configType string, cfgStructPtr interface{}) error {
+	configType = strings.ToLower(strings.TrimPrefix(configType, "."))
+	return configer.InitializeFromBytes(data, configType, cfgStructPtr)
+}
+
+// URLOption options for InitializeFromURL
+type URLOption func(*urlOptions)
+
+type urlOptions struct {
+	client     *http.Client
+	timeout    time.Duration
+	configType string
+	checksum   string
+	header     http.Header
+}
+
+// WithHTTPClient use client to fetch http(s):// config
+func WithHTTPClient(client *http.Client) URLOption {
+	return func(o *urlOptions) {
+		o.client = client
+	}
+}
+
+// WithTimeout overrides DefaultURLTimeout
+func WithTimeout(timeout time.Duration) URLOption {
+	return func(o *urlOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithConfigType sets config type when URL path has no extension, e.g. json
+func WithConfigType(configType string) URLOption {
+	return func(o *urlOptions) {
+		o.configType = configType
+	}
+}
+
+// WithChecksum verifies content before parsing,
+// format: "sha256:<hex>", "sha512:<hex>" or "<hex>" (sha256)
+func WithChecksum(checksum string) URLOption {
+	return func(o *urlOptions) {
+		o.checksum = checksum
+	}
+}
+
+// WithHeader adds request header for http(s):// config, e.g. Authorization
+func WithHeader(key, value string) URLOption {
+	return func(o *urlOptions) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// InitializeFromURL init your config from http://, https:// or file:// URL, Reload fetches it again
+func InitializeFromURL(rawURL string, cfgStructPtr interface{}, opts ...URLOption) error {
+	o := &urlOptions{timeout: DefaultURLTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return xerr.WithStack(err)
+	}
+
+	configType := o.configType
+	if configType == "" {
+		_, configType = fileInfo(path.Base(u.Path))
+	}
+
+	var fetch func() ([]byte, error)
+	switch u.Scheme {
+	case "http", "https":
+		fetch = func() ([]byte, error) { return fetchURL(u, o) }
+	case "file":
+		fetch = func() ([]byte, error) { return afero.ReadFile(configer.fs, filepath.FromSlash(u.Host+u.Path)) }
+	default:
+		return UnsupportedURLError(rawURL)
+	}
+
+	// Reload fetches the URL again, the checksum is verified every time
+	configType = strings.ToLower(strings.TrimPrefix(configType, "."))
+	return configer.initializeFromFetch(func() ([]byte, error) {
+		data, err := fetch()
+		if err != nil {
+			return nil, xerr.WithStack(err)
+		}
+		if o.checksum != "" {
+			if err := verifyChecksum(data, o.checksum); err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	}, configType, cfgStructPtr)
+}
+
+func fetchURL(u *url.URL, o *urlOptions) ([]byte, error) {
+	client := o.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx := context.Background()
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, vv := range o.header {
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("fetch config %s: unexpected status %s", u.Redacted(), resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func verifyChecksum(data []byte, checksum string) error {
+	algo, expected := "sha256", checksum
+	if i := strings.Index(checksum, ":"); i >= 0 {
+		algo, expected = strings.ToLower(checksum[:i]), checksum[i+1:]
+	}
+
+	var h hash.Hash
+	switch algo {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return ChecksumError{algo: algo, expected: expected}
+	}
+
+	_, _ = h.Write(data)
+	actual := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(actual, expected) {
+		return ChecksumError{algo: algo, expected: expected, actual: actual}
+	}
+	return nil
+}
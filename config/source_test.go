@@ -0,0 +1,85 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func (suite *Suite) TestReader() {
+	suite.T().Setenv("APP_DEBUG", "false")
+
+	cfg := &AppConfig{}
+	suite.Require().NoError(InitializeFromReader(strings.NewReader(yamlExample), "yaml", cfg))
+	suite.Equal("test-app", cfg.AppName)
+	suite.Equal(false, cfg.Debug)
+	suite.Equal("root@tcp(localhost:3306)/test", cfg.Database.DSN)
+
+	suite.Error(InitializeFromBytes([]byte(yamlExample), "ini", &AppConfig{}))
+	suite.Error(InitializeFromBytes([]byte("{"), "json", &AppConfig{}))
+}
+
+func (suite *Suite) TestURL() {
+	sum := sha256.Sum256([]byte(jsonExample))
+	checksum := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.json":
+			_, _ = w.Write([]byte(jsonExample))
+		case "/config":
+			_, _ = w.Write([]byte(tomlExample))
+		case "/slow.json":
+			time.Sleep(200 * time.Millisecond)
+			_, _ = w.Write([]byte(jsonExample))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &AppConfig{}
+	suite.Require().NoError(InitializeFromURL(server.URL+"/config.json", cfg, WithChecksum("sha256:"+checksum)))
+	suite.Equal("test-app", cfg.AppName)
+	suite.Equal("root@tcp(localhost:3306)/test", cfg.Database.DSN)
+
+	cfg = &AppConfig{}
+	suite.Require().NoError(InitializeFromURL(server.URL+"/config", cfg, WithConfigType("toml")))
+	suite.Equal("test-app", cfg.AppName)
+
+	err := InitializeFromURL(server.URL+"/config.json", &AppConfig{}, WithChecksum(strings.Repeat("0", 64)))
+	suite.IsType(ChecksumError{}, err)
+
+	suite.Error(InitializeFromURL(server.URL+"/missing.json", &AppConfig{}))
+	suite.Error(InitializeFromURL(server.URL+"/slow.json", &AppConfig{}, WithTimeout(50*time.Millisecond)))
+	suite.IsType(UnsupportedURLError(""), InitializeFromURL("ftp://localhost/config.json", &AppConfig{}))
+
+	abs, err := filepath.Abs(suite.JSONFile)
+	suite.Require().NoError(err)
+	cfg = &AppConfig{}
+	suite.Require().NoError(InitializeFromURL("file://"+filepath.ToSlash(abs), cfg, WithChecksum(checksum)))
+	suite.Equal("test-app", cfg.AppName)
+}
+
+func (suite *Suite) TestReloadURL() {
+	content := jsonExample
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	suite.Require().NoError(InitializeFromURL(server.URL+"/config.json", &AppConfig{}))
+	suite.Equal("test-app", Get().(*AppConfig).AppName)
+
+	content = strings.Replace(jsonExample, "test-app", "reloaded-app", 1)
+	suite.Require().NoError(Reload())
+	suite.Equal("reloaded-app", Get().(*AppConfig).AppName)
+
+	server.Close()
+	suite.Error(Reload())
+	suite.Equal("reloaded-app", Get().(*AppConfig).AppName, "failed reload keeps the current config")
+}
@@ -22,6 +22,7 @@ import (
 	"context"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/zhaolion/gostack/util/log"
 	"github.com/zhaolion/gostack/util/xerr"
@@ -35,9 +36,17 @@ func Register(container interface{}) {
 	entry.Register(container)
 }
 
-// Run runs all the global cleanup functions registered.
+// FlushTimeout max time waiting for pending error reports when Run
+var FlushTimeout = 5 * time.Second
+
+// Run runs all the global cleanup functions registered,
+// then flushes pending reports of xerr reporters.
 func Run() {
 	entry.Run()
+
+	if !xerr.FlushReporters(FlushTimeout) {
+		log.Warnf("cleanup: flush error reporters timeout after %s", FlushTimeout)
+	}
 }
 
 type Entry struct {
@@ -0,0 +1,401 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Log formats
+const (
+	FormatJSON   = "json"
+	FormatText   = "text"
+	FormatLogfmt = "logfmt"
+)
+
+// Log outputs, other values are treated as file path
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// Config 日志配置, 可以嵌入服务配置中由 config.Initialize 加载, 然后调用 Apply 生效
+//
+//	type AppConfig struct {
+//		Log log.Config `json:"log" yaml:"log"`
+//	}
+type Config struct {
+	// Level panic, fatal, error, warn, info, debug, trace, default info
+	Level string `json:"level" yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	// Format json, text or logfmt, default json
+	Format string `json:"format" yaml:"format" toml:"format" env:"LOG_FORMAT"`
+	// Output stdout, stderr or a file path (appended), default stdout
+	Output string `json:"output" yaml:"output" toml:"output" env:"LOG_OUTPUT"`
+	// Caller reports the calling function and file
+	Caller bool `json:"caller" yaml:"caller" toml:"caller" env:"LOG_CALLER"`
+	// TimeFormat layout of the timestamp, default time.RFC3339
+	TimeFormat string `json:"time_format" yaml:"time_format" toml:"time_format" env:"LOG_TIME_FORMAT"`
+	// FieldMap renames default fields, keys: time, level, msg, func, file
+	FieldMap map[string]string `json:"field_map" yaml:"field_map" toml:"field_map"`
+	// Rotate rotates the output file, ignored for stdout/stderr
+	Rotate *RotateConfig `json:"rotate" yaml:"rotate" toml:"rotate"`
+	// Sampling samples output of hot messages, see Sampler
+	Sampling *SamplingConfig `json:"sampling" yaml:"sampling" toml:"sampling"`
+	// Redact redacts sensitive fields and messages, see RedactHook
+	Redact *RedactConfig `json:"redact" yaml:"redact" toml:"redact"`
+}
+
+// RotateConfig rotation of the output file, see RotateWriter
+type RotateConfig struct {
+	// MaxSizeMB rotates the file before it grows beyond MaxSizeMB megabytes
+	MaxSizeMB int `json:"max_size_mb" yaml:"max_size_mb" toml:"max_size_mb"`
+	// Interval rotates the file by time, e.g. 1h, 24h
+	Interval string `json:"interval" yaml:"interval" toml:"interval"`
+	// MaxBackups max number of rotated files to keep
+	MaxBackups int `json:"max_backups" yaml:"max_backups" toml:"max_backups"`
+	// MaxAge removes rotated files older than it, e.g. 168h
+	MaxAge string `json:"max_age" yaml:"max_age" toml:"max_age"`
+	// Compress compresses rotated files with gzip
+	Compress bool `json:"compress" yaml:"compress" toml:"compress"`
+}
+
+func (c *RotateConfig) writer(filename string) (*RotateWriter, error) {
+	w := &RotateWriter{
+		Filename:   filename,
+		MaxSize:    int64(c.MaxSizeMB) << 20,
+		MaxBackups: c.MaxBackups,
+		Compress:   c.Compress,
+	}
+
+	var err error
+	if c.Interval != "" {
+		if w.Interval, err = time.ParseDuration(c.Interval); err != nil {
+			return nil, fmt.Errorf("log: invalid rotate interval: %v", err)
+		}
+	}
+	if c.MaxAge != "" {
+		if w.MaxAge, err = time.ParseDuration(c.MaxAge); err != nil {
+			return nil, fmt.Errorf("log: invalid rotate max age: %v", err)
+		}
+	}
+	return w, nil
+}
+
+// SamplingConfig sampling of the output, see Sampler
+//
+//	sampling:
+//	  first: 100
+//	  thereafter: 100
+//	  levels:
+//	    debug: {first: 10}
+type SamplingConfig struct {
+	// Interval counting interval, default 1s
+	Interval string `json:"interval" yaml:"interval" toml:"interval"`
+	// First and Thereafter rule of levels not in Levels, ignored if both are 0.
+	// Panic and fatal entries are never sampled.
+	First      int `json:"first" yaml:"first" toml:"first"`
+	Thereafter int `json:"thereafter" yaml:"thereafter" toml:"thereafter"`
+	// Levels rules of levels, a level with both first and thereafter 0 (e.g. error: {}) is not sampled
+	Levels map[string]Sampling `json:"levels" yaml:"levels" toml:"levels"`
+	// ReportInterval logs dropped counts as warnings every interval, e.g. 1m, disabled if empty
+	ReportInterval string `json:"report_interval" yaml:"report_interval" toml:"report_interval"`
+}
+
+func (c *SamplingConfig) sampler() (*Sampler, time.Duration, error) {
+	interval, report := time.Second, time.Duration(0)
+	var err error
+	if c.Interval != "" {
+		if interval, err = time.ParseDuration(c.Interval); err != nil {
+			return nil, 0, fmt.Errorf("log: invalid sampling interval: %v", err)
+		}
+	}
+	if c.ReportInterval != "" {
+		if report, err = time.ParseDuration(c.ReportInterval); err != nil || report <= 0 {
+			return nil, 0, fmt.Errorf("log: invalid sampling report interval %q", c.ReportInterval)
+		}
+	}
+
+	rules := make(map[Level]Sampling)
+	if c.First > 0 || c.Thereafter > 0 {
+		for _, level := range logrus.AllLevels {
+			if level > FatalLevel {
+				rules[level] = Sampling{First: c.First, Thereafter: c.Thereafter}
+			}
+		}
+	}
+	for name, rule := range c.Levels {
+		level, err := logrus.ParseLevel(name)
+		if err != nil {
+			return nil, 0, fmt.Errorf("log: invalid sampling level: %v", err)
+		}
+		// 都为 0 的规则覆盖默认规则, NewSampler 会跳过, 即该 level 不采样
+		rules[level] = rule
+	}
+	return NewSampler(interval, rules), report, nil
+}
+
+// RedactConfig redaction of entries, see RedactHook
+//
+//	redact:
+//	  default: true
+//	  rules:
+//	    - fields: [id_card]
+//	      strategy: partial
+//	    - pattern: email
+//	      strategy: hash
+type RedactConfig struct {
+	// Default uses DefaultRedactRules
+	Default bool `json:"default" yaml:"default" toml:"default"`
+	// Rules extra rules, applied after the default ones
+	Rules []RedactRuleConfig `json:"rules" yaml:"rules" toml:"rules"`
+}
+
+// RedactRuleConfig see RedactRule
+type RedactRuleConfig struct {
+	Fields []string `json:"fields" yaml:"fields" toml:"fields"`
+	// Pattern regexp, or a built-in pattern: email, bearer, card, mobile
+	Pattern string `json:"pattern" yaml:"pattern" toml:"pattern"`
+	// Strategy full, partial or hash, default full
+	Strategy string `json:"strategy" yaml:"strategy" toml:"strategy"`
+}
+
+func (c *RedactConfig) hook() (*RedactHook, error) {
+	var rules []RedactRule
+	if c.Default {
+		rules = DefaultRedactRules()
+	}
+	for _, rc := range c.Rules {
+		rule := RedactRule{Fields: rc.Fields, Strategy: RedactStrategy(strings.ToLower(rc.Strategy))}
+		switch strings.ToLower(rc.Pattern) {
+		case "":
+		case "email":
+			rule.Pattern = EmailPattern
+		case "bearer":
+			rule.Pattern = BearerPattern
+		case "card":
+			rule.Pattern, rule.Validate = CardNumberPattern, luhnValid
+		case "mobile":
+			rule.Pattern = MobilePattern
+		default:
+			var err error
+			if rule.Pattern, err = regexp.Compile(rc.Pattern); err != nil {
+				return nil, fmt.Errorf("log: invalid redact pattern: %v", err)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return NewRedactHook(rules...)
+}
+
+// Apply applies config to the standard logger
+func (c *Config) Apply() error {
+	return c.ApplyTo(StandardLogger())
+}
+
+// ApplyTo applies config to logger, nothing is changed if config is invalid
+func (c *Config) ApplyTo(logger *Logger) error {
+	level := InfoLevel
+	if c.Level != "" {
+		var err error
+		if level, err = logrus.ParseLevel(c.Level); err != nil {
+			return err
+		}
+	}
+
+	fieldMap, err := c.fieldMap()
+	if err != nil {
+		return err
+	}
+	formatter, err := c.formatter(fieldMap)
+	if err != nil {
+		return err
+	}
+	var (
+		sampler *Sampler
+		report  time.Duration
+	)
+	if c.Sampling != nil {
+		if sampler, report, err = c.Sampling.sampler(); err != nil {
+			return err
+		}
+		formatter = &samplingFormatter{Formatter: formatter, sampler: sampler}
+	}
+	var redactHook *RedactHook
+	if c.Redact != nil {
+		if redactHook, err = c.Redact.hook(); err != nil {
+			return err
+		}
+	}
+	out, closer, err := c.openOutput()
+	if err != nil {
+		return err
+	}
+
+	logger.SetFormatter(formatter)
+	logger.SetOutput(out)
+	logger.SetReportCaller(c.Caller)
+	logger.SetLevel(level)
+	setRedactHook(logger, redactHook)
+	if logger == StandardLogger() {
+		// 先切换 named logger 的输出, 再关闭之前的
+		syncNamed()
+	}
+	swapOutput(logger, closer)
+
+	var stop func()
+	if sampler != nil && report > 0 {
+		stop = sampler.ReportDropped(report, logDropped(logger))
+	}
+	swapReporter(logger, stop)
+	return nil
+}
+
+func (c *Config) fieldMap() (FieldMap, error) {
+	fieldMap := FieldMap{}
+	for key, rename := range c.FieldMap {
+		switch strings.ToLower(key) {
+		case logrus.FieldKeyTime:
+			fieldMap[logrus.FieldKeyTime] = rename
+		case logrus.FieldKeyLevel:
+			fieldMap[logrus.FieldKeyLevel] = rename
+		case logrus.FieldKeyMsg:
+			fieldMap[logrus.FieldKeyMsg] = rename
+		case logrus.FieldKeyFunc:
+			fieldMap[logrus.FieldKeyFunc] = rename
+		case logrus.FieldKeyFile:
+			fieldMap[logrus.FieldKeyFile] = rename
+		default:
+			return nil, fmt.Errorf("log: unknown field %q in field map", key)
+		}
+	}
+	return fieldMap, nil
+}
+
+func (c *Config) formatter(fieldMap FieldMap) (logrus.Formatter, error) {
+	switch strings.ToLower(c.Format) {
+	case "", FormatJSON:
+		return &logrus.JSONFormatter{
+			TimestampFormat:  c.TimeFormat,
+			FieldMap:         fieldMap,
+			CallerPrettyfier: callerPrettyfier,
+		}, nil
+	case FormatText:
+		return &logrus.TextFormatter{
+			FullTimestamp:    true,
+			TimestampFormat:  c.TimeFormat,
+			FieldMap:         fieldMap,
+			CallerPrettyfier: callerPrettyfier,
+		}, nil
+	case FormatLogfmt:
+		return &logrus.TextFormatter{
+			DisableColors:    true,
+			FullTimestamp:    true,
+			TimestampFormat:  c.TimeFormat,
+			FieldMap:         fieldMap,
+			CallerPrettyfier: callerPrettyfier,
+		}, nil
+	default:
+		return nil, fmt.Errorf("log: unsupported format %q", c.Format)
+	}
+}
+
+func callerPrettyfier(f *runtime.Frame) (string, string) {
+	filename := path.Base(f.File)
+	return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", filename, f.Line)
+}
+
+// outputs opened by Config, closed when the logger output is replaced
+var outputs struct {
+	mu     sync.Mutex
+	opened map[*Logger]io.Closer
+	// stops dropped count reporters started by Config
+	reporters map[*Logger]func()
+}
+
+func (c *Config) openOutput() (io.Writer, io.Closer, error) {
+	switch strings.ToLower(c.Output) {
+	case "", OutputStdout:
+		return os.Stdout, nil, nil
+	case OutputStderr:
+		return os.Stderr, nil, nil
+	}
+
+	if c.Rotate != nil {
+		w, err := c.Rotate.writer(c.Output)
+		if err != nil {
+			return nil, nil, err
+		}
+		return w, w, nil
+	}
+
+	f, err := os.OpenFile(c.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f, nil
+}
+
+// swapOutput records output opened for logger, closes the previous one
+func swapOutput(logger *Logger, closer io.Closer) {
+	outputs.mu.Lock()
+	defer outputs.mu.Unlock()
+	if outputs.opened == nil {
+		outputs.opened = make(map[*Logger]io.Closer)
+	}
+	if prev := outputs.opened[logger]; prev != nil && prev != closer {
+		_ = prev.Close()
+	}
+	outputs.opened[logger] = closer
+}
+
+// swapReporter records the dropped count reporter of logger, stops the previous one
+func swapReporter(logger *Logger, stop func()) {
+	outputs.mu.Lock()
+	defer outputs.mu.Unlock()
+	if outputs.reporters == nil {
+		outputs.reporters = make(map[*Logger]func())
+	}
+	if prev := outputs.reporters[logger]; prev != nil {
+		prev()
+	}
+	outputs.reporters[logger] = stop
+}
+
+// setRedactHook replaces the redact hook of logger, it is fired before other hooks
+func setRedactHook(logger *Logger, hook *RedactHook) {
+	if hook == nil && !hasRedactHook(logger) {
+		return
+	}
+
+	hooks := make(logrus.LevelHooks)
+	for _, level := range AllLevels {
+		if hook != nil {
+			hooks[level] = append(hooks[level], hook)
+		}
+		for _, h := range logger.Hooks[level] {
+			if _, ok := h.(*RedactHook); !ok {
+				hooks[level] = append(hooks[level], h)
+			}
+		}
+	}
+	logger.ReplaceHooks(hooks)
+}
+
+func hasRedactHook(logger *Logger) bool {
+	for _, hooks := range logger.Hooks {
+		for _, h := range hooks {
+			if _, ok := h.(*RedactHook); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
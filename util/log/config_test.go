@@ -0,0 +1,56 @@
+package log
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigApply(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	logger := New()
+
+	cfg := &Config{
+		Level:      "warn",
+		Output:     file,
+		Caller:     true,
+		TimeFormat: "2006-01-02",
+		FieldMap:   map[string]string{"msg": "message", "time": "@timestamp"},
+	}
+	require.NoError(t, cfg.ApplyTo(logger))
+	assert.Equal(t, WarnLevel, logger.GetLevel())
+
+	logger.Info("hidden")
+	logger.WithField("user_id", 1).Warn("visible")
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+	entry := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "visible", entry["message"])
+	assert.Len(t, entry["@timestamp"], len("2006-01-02"))
+	assert.Equal(t, float64(1), entry["user_id"])
+	assert.Contains(t, entry["file"], "config_test.go")
+
+	// logfmt 输出到 stderr, 之前打开的文件被关闭
+	require.NoError(t, (&Config{Format: "logfmt", Output: "stderr"}).ApplyTo(logger))
+	assert.Equal(t, InfoLevel, logger.GetLevel())
+	assert.Equal(t, os.Stderr, logger.Out)
+	assert.False(t, logger.ReportCaller)
+}
+
+func TestConfigInvalid(t *testing.T) {
+	logger := New()
+	assert.Error(t, (&Config{Level: "verbose"}).ApplyTo(logger))
+	assert.Error(t, (&Config{Format: "xml"}).ApplyTo(logger))
+	assert.Error(t, (&Config{FieldMap: map[string]string{"caller": "c"}}).ApplyTo(logger))
+	assert.Error(t, (&Config{Output: filepath.Join(t.TempDir(), "missing", "app.log")}).ApplyTo(logger))
+	assert.Equal(t, InfoLevel, logger.GetLevel(), "nothing is changed")
+}
@@ -13,11 +13,14 @@ func ToContextFields(ctx context.Context, fields Fields) context.Context {
 }
 
 // Ctx creates an entry from the standard logger and adds a context to it.
-// Add a single field(trace_id) to the Entry
+// Add fields request_id, trace_id and span_id carried by ctx to the Entry, see TraceMiddleware
 func Ctx(ctx context.Context) *Entry {
-	if entry, ok := ctx.Value(ctxMarker).(*Entry); ok && entry != nil {
-		return entry
+	entry, ok := ctx.Value(ctxMarker).(*Entry)
+	if !ok || entry == nil {
+		entry = logrus.WithContext(ctx)
+	}
+	if fields := traceFields(ctx, entry.Data); fields != nil {
+		entry = entry.WithFields(fields)
 	}
-	entry := logrus.WithContext(ctx)
 	return entry
 }
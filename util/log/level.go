@@ -0,0 +1,160 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+var named struct {
+	mu      sync.RWMutex
+	loggers map[string]*Logger
+}
+
+// Named return the logger of name, it is created from the standard logger at the first call
+// with its own level, so the level of a module can be changed at runtime, see LevelHandler.
+// Output, formatter, hooks and caller reporting follow the standard logger when they are
+// changed by util/log (Config.Apply, SetOutput, SetFormatter, AddHook, SetSampler).
+func Named(name string) *Logger {
+	named.mu.RLock()
+	logger, ok := named.loggers[name]
+	named.mu.RUnlock()
+	if ok {
+		return logger
+	}
+
+	named.mu.Lock()
+	defer named.mu.Unlock()
+	if logger, ok := named.loggers[name]; ok {
+		return logger
+	}
+	if named.loggers == nil {
+		named.loggers = make(map[string]*Logger)
+	}
+
+	std := StandardLogger()
+	logger = New()
+	logger.ExitFunc = std.ExitFunc
+	logger.SetLevel(std.GetLevel())
+	inherit(logger, std)
+	named.loggers[name] = logger
+	return logger
+}
+
+// syncNamed applies output, formatter, hooks and caller reporting of the standard logger
+// to the named loggers
+func syncNamed() {
+	std := StandardLogger()
+	named.mu.RLock()
+	defer named.mu.RUnlock()
+	for _, logger := range named.loggers {
+		inherit(logger, std)
+	}
+}
+
+// inherit copies settings except level from std, hooks are copied so AddHook
+// of one logger doesn't change the other
+func inherit(logger, std *Logger) {
+	hooks := make(logrus.LevelHooks, len(std.Hooks))
+	for level, levelHooks := range std.Hooks {
+		hooks[level] = append([]Hook(nil), levelHooks...)
+	}
+
+	logger.SetOutput(std.Out)
+	logger.SetFormatter(std.Formatter)
+	logger.SetReportCaller(std.ReportCaller)
+	logger.ReplaceHooks(hooks)
+}
+
+// SetNamedLevel sets level of the named logger, the logger must be created by Named
+func SetNamedLevel(name string, level Level) error {
+	named.mu.RLock()
+	logger, ok := named.loggers[name]
+	named.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("log: logger %q not found", name)
+	}
+
+	logger.SetLevel(level)
+	return nil
+}
+
+// Levels level of the standard logger and levels of the named loggers
+type Levels struct {
+	Level   string            `json:"level"`
+	Loggers map[string]string `json:"loggers,omitempty"`
+}
+
+// CurrentLevels return current levels
+func CurrentLevels() Levels {
+	levels := Levels{Level: StandardLogger().GetLevel().String()}
+
+	named.mu.RLock()
+	defer named.mu.RUnlock()
+	if len(named.loggers) != 0 {
+		levels.Loggers = make(map[string]string, len(named.loggers))
+	}
+	for name, logger := range named.loggers {
+		levels.Loggers[name] = logger.GetLevel().String()
+	}
+	return levels
+}
+
+// LevelHandler HTTP handler to get or change log levels at runtime:
+//
+//	GET /log/level                                   => {"level":"info","loggers":{"db":"warn"}}
+//	PUT /log/level {"level":"debug"}                 change the standard logger
+//	PUT /log/level {"level":"debug","logger":"db"}   change the named logger
+//
+// PUT also accepts query params, e.g. PUT /log/level?level=debug&logger=db
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			if status, err := changeLevel(r); err != nil {
+				http.Error(w, err.Error(), status)
+				return
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(CurrentLevels())
+	})
+}
+
+func changeLevel(r *http.Request) (int, error) {
+	req := struct {
+		Level  string `json:"level"`
+		Logger string `json:"logger"`
+	}{
+		Level:  r.URL.Query().Get("level"),
+		Logger: r.URL.Query().Get("logger"),
+	}
+	if req.Level == "" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("log: invalid request: %v", err)
+		}
+	}
+
+	level, err := logrus.ParseLevel(req.Level)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if req.Logger == "" {
+		SetLevel(level)
+		Warnf("log: level changed to %s", level)
+		return http.StatusOK, nil
+	}
+	if err := SetNamedLevel(req.Logger, level); err != nil {
+		return http.StatusNotFound, err
+	}
+	Warnf("log: level of %s changed to %s", req.Logger, level)
+	return http.StatusOK, nil
+}
@@ -0,0 +1,98 @@
+//go:build !windows
+
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelHandler(t *testing.T) {
+	defer SetLevel(StandardLogger().GetLevel())
+	SetLevel(InfoLevel)
+
+	db := Named("db")
+	assert.Same(t, db, Named("db"))
+	require.NoError(t, SetNamedLevel("db", InfoLevel))
+
+	handler := LevelHandler()
+	do := func(method, target, body string) (*httptest.ResponseRecorder, Levels) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		levels := Levels{}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &levels))
+		}
+		return w, levels
+	}
+
+	_, levels := do(http.MethodGet, "/log/level", "")
+	assert.Equal(t, "info", levels.Level)
+	assert.Equal(t, "info", levels.Loggers["db"])
+
+	_, levels = do(http.MethodPut, "/log/level", `{"level":"warn"}`)
+	assert.Equal(t, "warning", levels.Level)
+	assert.Equal(t, WarnLevel, StandardLogger().GetLevel())
+
+	_, levels = do(http.MethodPut, "/log/level?level=debug&logger=db", "")
+	assert.Equal(t, "debug", levels.Loggers["db"])
+	assert.Equal(t, DebugLevel, db.GetLevel())
+	assert.Equal(t, WarnLevel, StandardLogger().GetLevel())
+
+	w, _ := do(http.MethodPut, "/log/level", `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = do(http.MethodPut, "/log/level", `{"level":"debug","logger":"missing"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w, _ = do(http.MethodPost, "/log/level", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestToggleDebugOnSignal(t *testing.T) {
+	defer SetLevel(StandardLogger().GetLevel())
+	SetLevel(WarnLevel)
+
+	stop := ToggleDebugOnSignal(100 * time.Millisecond)
+	defer stop()
+	assert.True(t, DebugToggleEnabled())
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool { return StandardLogger().GetLevel() == DebugLevel }, time.Second, 5*time.Millisecond)
+	// 超时自动恢复
+	assert.Eventually(t, func() bool { return StandardLogger().GetLevel() == WarnLevel }, time.Second, 5*time.Millisecond)
+
+	stop()
+	assert.False(t, DebugToggleEnabled())
+}
+
+func TestNamedFollowsApply(t *testing.T) {
+	named := Named("apply")
+	defer (&Config{Level: "debug"}).Apply()
+
+	file := filepath.Join(t.TempDir(), "app.log")
+	cfg := &Config{Output: file, Redact: &RedactConfig{Default: true}}
+	require.NoError(t, cfg.Apply())
+	require.NoError(t, SetNamedLevel("apply", InfoLevel))
+
+	named.WithField("password", "p@ss").Info("token of alice@example.com")
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), RedactedValue)
+	assert.NotContains(t, string(data), "p@ss")
+	assert.NotContains(t, string(data), "alice@example.com")
+
+	// hooks are not shared
+	AddHook(&RedactHook{})
+	named.AddHook(&RedactHook{})
+	assert.Len(t, named.Hooks[InfoLevel], 3)
+	assert.Len(t, StandardLogger().Hooks[InfoLevel], 2)
+}
@@ -0,0 +1,73 @@
+//go:build !windows
+
+package log
+
+import (
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DebugToggleEnabled reports whether ToggleDebugOnSignal is enabled,
+// svcutil.WaitSignals doesn't exit on SIGUSR1 then.
+func DebugToggleEnabled() bool {
+	return SignalHandled(syscall.SIGUSR1)
+}
+
+// ToggleDebugOnSignal toggles the standard logger between its configured level and debug
+// on SIGUSR1, the level is reverted automatically after revertAfter (never if <= 0).
+// Call the returned function to stop.
+//
+//	kill -USR1 <pid>  # debug on, again to revert
+func ToggleDebugOnSignal(revertAfter time.Duration) (stop func()) {
+	var (
+		mu         sync.Mutex
+		configured Level
+		toggled    bool
+		timer      *time.Timer
+		generation int
+	)
+	// restore must be called with mu held
+	restore := func() {
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+		toggled = false
+		SetLevel(configured)
+		Warnf("log: level reverted to %s", configured)
+	}
+
+	stopSignal := notifySignal(syscall.SIGUSR1, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if toggled {
+			restore()
+			return
+		}
+
+		configured, toggled = StandardLogger().GetLevel(), true
+		generation++
+		SetLevel(DebugLevel)
+		Warnf("log: level changed from %s to debug by signal", configured)
+		if revertAfter > 0 {
+			gen := generation
+			timer = time.AfterFunc(revertAfter, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				if toggled && gen == generation {
+					restore()
+				}
+			})
+		}
+	})
+
+	return func() {
+		stopSignal()
+		mu.Lock()
+		defer mu.Unlock()
+		if toggled {
+			restore()
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package log
+
+import "time"
+
+// DebugToggleEnabled always false, SIGUSR1 is not available on windows
+func DebugToggleEnabled() bool {
+	return false
+}
+
+// ToggleDebugOnSignal does nothing, SIGUSR1 is not available on windows
+func ToggleDebugOnSignal(revertAfter time.Duration) (stop func()) {
+	return func() {}
+}
@@ -1,10 +1,8 @@
 package log
 
 import (
-	"fmt"
+	"io"
 	"os"
-	"path"
-	"runtime"
 
 	"github.com/sirupsen/logrus"
 )
@@ -12,10 +10,7 @@ import (
 func init() {
 	// Log as JSON instead of the default ASCII formatter.
 	logrus.SetFormatter(&logrus.JSONFormatter{
-		CallerPrettyfier: func(f *runtime.Frame) (string, string) {
-			filename := path.Base(f.File)
-			return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", filename, f.Line)
-		},
+		CallerPrettyfier: callerPrettyfier,
 	})
 
 	// Output to stdout instead of the default stderr
@@ -67,6 +62,16 @@ type Level = logrus.Level
 // Logger type
 type Logger = logrus.Logger
 
+// Hook to be fired when logging on the logging levels returned from
+// `Levels()` on your implementation of the interface.
+type Hook = logrus.Hook
+
+// ErrorKey defines the key when adding errors using WithError.
+var ErrorKey = logrus.ErrorKey
+
+// AllLevels exposing all logging levels
+var AllLevels = logrus.AllLevels
+
 // JSONFormatter formats logs into parsable json
 type JSONFormatter struct {
 	logrus.JSONFormatter
@@ -94,6 +99,24 @@ func SetLevel(level Level) {
 	logrus.SetLevel(level)
 }
 
+// SetOutput sets the standard logger output, named loggers follow it
+func SetOutput(out io.Writer) {
+	logrus.SetOutput(out)
+	syncNamed()
+}
+
+// SetFormatter sets the standard logger formatter, named loggers follow it
+func SetFormatter(formatter logrus.Formatter) {
+	logrus.SetFormatter(formatter)
+	syncNamed()
+}
+
+// AddHook adds a hook to the standard logger hooks, named loggers follow it
+func AddHook(hook Hook) {
+	logrus.AddHook(hook)
+	syncNamed()
+}
+
 // NewWithFields returns a logrus Entry with fields
 func NewWithFields(fields Fields) *Entry {
 	return logrus.WithFields(fields)
@@ -120,10 +143,6 @@ var (
 	New = logrus.New
 	// StandardLogger default logger
 	StandardLogger = logrus.StandardLogger
-	// SetOutput sets the standard logger output.
-	SetOutput = logrus.SetOutput
-	// SetFormatter sets the standard logger formatter.
-	SetFormatter = logrus.SetFormatter
 	// WithError creates an entry from the standard logger and adds an error to it, using the value defined in ErrorKey as key.
 	WithError = logrus.WithError
 	// WithField creates an entry from the standard logger and adds a field to
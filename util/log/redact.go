@@ -0,0 +1,258 @@
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// RedactStrategy 脱敏方式
+type RedactStrategy string
+
+// Redact strategies
+const (
+	// RedactFull replaces the value with RedactedValue
+	RedactFull RedactStrategy = "full"
+	// RedactPartial keeps the first and last quarter (at most 4 characters each) of the value,
+	// e.g. 13812345678 => 13*******78
+	RedactPartial RedactStrategy = "partial"
+	// RedactHash replaces the value with its sha256 prefix, e.g. sha256:5e884898da28,
+	// equal values can still be correlated. Low-entropy values (e.g. phone numbers)
+	// can be brute-forced, use it for tokens and ids.
+	RedactHash RedactStrategy = "hash"
+)
+
+// RedactedValue replacement of RedactFull
+const RedactedValue = "[REDACTED]"
+
+// Built-in patterns, matches of the first submatch (if any) are redacted
+var (
+	EmailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	BearerPattern     = regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`)
+	CardNumberPattern = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+	MobilePattern     = regexp.MustCompile(`\b1[3-9]\d{9}\b`)
+)
+
+// DefaultRedactFields names of fields redacted by DefaultRedactRules
+var DefaultRedactFields = []string{
+	"password", "passwd", "secret", "token", "access_token", "refresh_token",
+	"authorization", "cookie", "api_key", "apikey", "phone", "mobile",
+}
+
+// RedactRule 脱敏规则, 按字段名或者正则匹配, 二者至少有一个
+type RedactRule struct {
+	// Fields names of fields to redact, case-insensitive
+	Fields []string
+	// Pattern redacts matches in messages and string (or error) fields
+	Pattern *regexp.Regexp
+	// Validate filters matches of Pattern, e.g. Luhn check of card numbers, optional
+	Validate func(match string) bool
+	// Strategy default RedactFull
+	Strategy RedactStrategy
+}
+
+// DefaultRedactRules redacts DefaultRedactFields, bearer tokens and card numbers fully,
+// emails and mobile numbers partially
+func DefaultRedactRules() []RedactRule {
+	return []RedactRule{
+		{Fields: DefaultRedactFields, Strategy: RedactFull},
+		{Pattern: BearerPattern, Strategy: RedactFull},
+		{Pattern: CardNumberPattern, Validate: luhnValid, Strategy: RedactFull},
+		{Pattern: EmailPattern, Strategy: RedactPartial},
+		{Pattern: MobilePattern, Strategy: RedactPartial},
+	}
+}
+
+type patternRule struct {
+	pattern  *regexp.Regexp
+	validate func(string) bool
+	strategy RedactStrategy
+	// hint cheap check before matching, strings failing it never match
+	hint func(string) bool
+}
+
+// patternHints hints of built-in patterns, most strings are skipped without running regexps
+var patternHints = map[*regexp.Regexp]func(string) bool{
+	EmailPattern:      func(s string) bool { return strings.IndexByte(s, '@') >= 0 },
+	BearerPattern:     func(s string) bool { return containsFold(s, "bearer") },
+	CardNumberPattern: func(s string) bool { return hasDigits(s, 13) },
+	MobilePattern:     func(s string) bool { return hasDigits(s, 11) },
+}
+
+// RedactHook logrus hook redacting fields and messages of entries, add it before
+// other hooks so they see redacted entries:
+//
+//	hook, err := log.NewRedactHook(log.DefaultRedactRules()...)
+//	log.AddHook(hook)
+type RedactHook struct {
+	fields   map[string]RedactStrategy
+	patterns []patternRule
+}
+
+// NewRedactHook return a hook redacting by rules
+func NewRedactHook(rules ...RedactRule) (*RedactHook, error) {
+	h := &RedactHook{fields: make(map[string]RedactStrategy)}
+	for _, rule := range rules {
+		strategy := rule.Strategy
+		switch strategy {
+		case "":
+			strategy = RedactFull
+		case RedactFull, RedactPartial, RedactHash:
+		default:
+			return nil, fmt.Errorf("log: unknown redact strategy %q", rule.Strategy)
+		}
+		if len(rule.Fields) == 0 && rule.Pattern == nil {
+			return nil, fmt.Errorf("log: redact rule without fields or pattern")
+		}
+
+		for _, name := range rule.Fields {
+			h.fields[strings.ToLower(name)] = strategy
+		}
+		if rule.Pattern != nil {
+			h.patterns = append(h.patterns, patternRule{
+				pattern:  rule.Pattern,
+				validate: rule.Validate,
+				strategy: strategy,
+				hint:     patternHints[rule.Pattern],
+			})
+		}
+	}
+	return h, nil
+}
+
+// Levels all levels
+func (h *RedactHook) Levels() []Level {
+	return AllLevels
+}
+
+// Fire redacts the entry in place, entries are copied by logrus before hooks are fired
+func (h *RedactHook) Fire(entry *Entry) error {
+	entry.Message = h.RedactString(entry.Message)
+	for key, value := range entry.Data {
+		if strategy, ok := h.fieldStrategy(key); ok {
+			entry.Data[key] = redact(fmt.Sprint(value), strategy)
+			continue
+		}
+
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = h.RedactString(v)
+		case error:
+			if s := v.Error(); len(h.patterns) != 0 {
+				if redacted := h.RedactString(s); redacted != s {
+					entry.Data[key] = redacted
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (h *RedactHook) fieldStrategy(key string) (RedactStrategy, bool) {
+	if len(h.fields) == 0 {
+		return "", false
+	}
+	strategy, ok := h.fields[key]
+	if !ok {
+		strategy, ok = h.fields[strings.ToLower(key)]
+	}
+	return strategy, ok
+}
+
+// RedactString redacts matches of patterns in s
+func (h *RedactHook) RedactString(s string) string {
+	for _, rule := range h.patterns {
+		if rule.hint == nil || rule.hint(s) {
+			s = rule.replace(s)
+		}
+	}
+	return s
+}
+
+func (r patternRule) replace(s string) string {
+	matches := r.pattern.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		// 只脱敏第一个分组
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if r.validate != nil && !r.validate(s[start:end]) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(redact(s[start:end], r.strategy))
+		last = end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func redact(s string, strategy RedactStrategy) string {
+	switch strategy {
+	case RedactPartial:
+		n := utf8.RuneCountInString(s)
+		keep := n / 4
+		if keep > 4 {
+			keep = 4
+		}
+		runes := []rune(s)
+		return string(runes[:keep]) + strings.Repeat("*", n-2*keep) + string(runes[n-keep:])
+	case RedactHash:
+		sum := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	default:
+		return RedactedValue
+	}
+}
+
+func containsFold(s, substr string) bool {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDigits reports whether s contains at least n digits
+func hasDigits(s string, n int) bool {
+	for i := 0; i < len(s) && n > 0; i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			n--
+		}
+	}
+	return n == 0
+}
+
+// luhnValid reports whether the digits of s pass the Luhn check
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
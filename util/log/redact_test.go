@@ -0,0 +1,93 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactHook(t *testing.T) {
+	hook, err := NewRedactHook(append(DefaultRedactRules(),
+		RedactRule{Fields: []string{"id_card"}, Strategy: RedactPartial},
+		RedactRule{Fields: []string{"user_token"}, Strategy: RedactHash},
+	)...)
+	require.NoError(t, err)
+
+	entry := &Entry{
+		Message: "login alice@example.com with Authorization: Bearer eyJhbGciOi.J9x-y_z",
+		Data: Fields{
+			"Password":   "p@ss",
+			"id_card":    "110101199003070011",
+			"user_token": "abc",
+			"card":       "pay by 4111 1111 1111 1111, order 1234567890123",
+			"phone":      13812345678,
+			"contact":    "call 13812345678",
+			"error":      errors.New("send to bob@example.org failed"),
+			"count":      3,
+		},
+	}
+	require.NoError(t, hook.Fire(entry))
+
+	assert.Equal(t, "login alic*********.com with Authorization: Bearer [REDACTED]", entry.Message)
+	assert.Equal(t, Fields{
+		"Password":   RedactedValue,
+		"id_card":    "1101**********0011",
+		"user_token": "sha256:ba7816bf8f01",
+		"card":       "pay by [REDACTED], order 1234567890123",
+		"phone":      RedactedValue,
+		"contact":    "call 13*******78",
+		"error":      "send to bob*********org failed",
+		"count":      3,
+	}, entry.Data)
+
+	// 没有命中时不修改
+	entry = &Entry{Message: "nothing here", Data: Fields{"user": "alice", "err": errors.New("timeout")}}
+	require.NoError(t, hook.Fire(entry))
+	assert.Equal(t, "nothing here", entry.Message)
+	assert.Equal(t, Fields{"user": "alice", "err": errors.New("timeout")}, entry.Data)
+
+	_, err = NewRedactHook(RedactRule{Fields: []string{"a"}, Strategy: "mask"})
+	assert.Error(t, err)
+	_, err = NewRedactHook(RedactRule{Strategy: RedactFull})
+	assert.Error(t, err)
+}
+
+func TestConfigRedact(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New()
+	other := &RedactHook{}
+	logger.AddHook(other)
+
+	cfg := &Config{Redact: &RedactConfig{
+		Default: true,
+		Rules:   []RedactRuleConfig{{Pattern: `order-\d+`, Strategy: "hash"}},
+	}}
+	require.NoError(t, cfg.ApplyTo(logger))
+	require.NoError(t, cfg.ApplyTo(logger))
+	logger.SetOutput(buf)
+	require.Len(t, logger.Hooks[InfoLevel], 1, "redact hooks are replaced")
+
+	logger.WithField("token", "secret").Info("paid order-42 by 4111111111111111")
+	entry := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, RedactedValue, entry["token"])
+	assert.Regexp(t, regexp.MustCompile(`^paid sha256:[0-9a-f]{12} by \[REDACTED\]$`), entry["msg"])
+
+	require.NoError(t, (&Config{}).ApplyTo(logger))
+	assert.Empty(t, logger.Hooks[InfoLevel])
+	assert.Error(t, (&Config{Redact: &RedactConfig{Rules: []RedactRuleConfig{{Pattern: "("}}}}).ApplyTo(logger))
+}
+
+func BenchmarkRedactHookNoMatch(b *testing.B) {
+	hook, _ := NewRedactHook(DefaultRedactRules()...)
+	data := Fields{"user_id": "u-1024", "path": "/api/orders", "status": 200}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = hook.Fire(&Entry{Message: "request finished", Data: data})
+	}
+}
@@ -0,0 +1,326 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat timestamp of rotated files, e.g. app-20060102T150405.000.log
+const backupTimeFormat = "20060102T150405.000"
+
+// RotateWriter 按大小和时间切分的日志文件, 可以直接用于 SetOutput
+//
+//	w := &log.RotateWriter{Filename: "/var/log/app.log", MaxSize: 100 << 20, MaxBackups: 7, Compress: true}
+//	log.SetOutput(w)
+//	defer w.Close()
+//
+// Rotated files are renamed to name-<timestamp>.ext (.gz if compressed),
+// compression and cleanup of old files run in background.
+type RotateWriter struct {
+	// Filename file to write logs to, backups are kept in the same directory
+	Filename string
+	// MaxSize rotates the file before it grows beyond MaxSize bytes, 0 disables size rotation
+	MaxSize int64
+	// Interval rotates the file when the time crosses a multiple of Interval (e.g. 24h),
+	// 0 disables time rotation
+	Interval time.Duration
+	// MaxBackups max number of rotated files to keep, 0 keeps all
+	MaxBackups int
+	// MaxAge removes rotated files older than MaxAge, 0 keeps all
+	MaxAge time.Duration
+	// Compress compresses rotated files with gzip
+	Compress bool
+
+	mu     sync.Mutex
+	file   *os.File
+	closed bool
+	size   int64
+	period time.Time
+
+	millMu sync.Mutex
+	millWg sync.WaitGroup
+
+	// now for testing
+	now func() time.Time
+}
+
+// Write writes p to the current file, rotates it first if needed
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.openExisting(); err != nil {
+			return 0, err
+		}
+	}
+
+	rotate := w.Interval > 0 && !w.currentPeriod().Equal(w.period)
+	if w.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.MaxSize {
+		rotate = true
+	}
+	if rotate {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it as a backup and opens a new one
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen closes and reopens the file, e.g. after it is moved by logrotate
+func (w *RotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	return w.openExisting()
+}
+
+// ReopenOnSignal reopens the file on SIGHUP, svcutil.WaitSignals doesn't exit on SIGHUP then.
+// Call the returned function to stop.
+func (w *RotateWriter) ReopenOnSignal() (stop func()) {
+	return notifySignal(syscall.SIGHUP, func() {
+		if err := w.Reopen(); err != nil {
+			fmt.Fprintf(os.Stderr, "log: reopen %s failed: %v\n", w.Filename, err)
+		}
+	})
+}
+
+// Close closes the file and waits for background compression and cleanup,
+// writes after Close return os.ErrClosed
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	// 之后不会再有 triggerMill, millWg.Wait 是安全的
+	w.closed = true
+	err := w.close()
+	w.mu.Unlock()
+
+	w.millWg.Wait()
+	return err
+}
+
+func (w *RotateWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *RotateWriter) openExisting() error {
+	if err := os.MkdirAll(filepath.Dir(w.Filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	w.file, w.size = f, info.Size()
+	w.period = w.currentPeriod()
+	if w.Interval > 0 && info.Size() > 0 && info.ModTime().Before(w.period) {
+		// 进程重启时, 上个周期遗留的文件先切分
+		return w.rotate()
+	}
+	return nil
+}
+
+func (w *RotateWriter) rotate() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(w.Filename); err == nil {
+		if err := os.Rename(w.Filename, w.backupName()); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(w.Filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w.file, w.size = f, 0
+	w.period = w.currentPeriod()
+
+	w.triggerMill()
+	return nil
+}
+
+func (w *RotateWriter) backupName() string {
+	dir, prefix, ext := w.nameParts()
+	name := filepath.Join(dir, prefix+w.timeNow().Format(backupTimeFormat)+ext)
+	// 同一毫秒内多次切分
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			if _, err := os.Stat(name + ".gz"); os.IsNotExist(err) {
+				return name
+			}
+		}
+		name = filepath.Join(dir, fmt.Sprintf("%s%s.%d%s", prefix, w.timeNow().Format(backupTimeFormat), i, ext))
+	}
+}
+
+// nameParts returns dir, backup prefix ("app-") and ext (".log") of Filename
+func (w *RotateWriter) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(w.Filename)
+	base := filepath.Base(w.Filename)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func (w *RotateWriter) timeNow() time.Time {
+	if w.now != nil {
+		return w.now()
+	}
+	return time.Now()
+}
+
+func (w *RotateWriter) currentPeriod() time.Time {
+	if w.Interval <= 0 {
+		return time.Time{}
+	}
+	return w.timeNow().Truncate(w.Interval)
+}
+
+// triggerMill compresses and cleans up rotated files in background, runs are serialized
+func (w *RotateWriter) triggerMill() {
+	if !w.Compress && w.MaxBackups <= 0 && w.MaxAge <= 0 {
+		return
+	}
+
+	now := w.timeNow()
+	w.millWg.Add(1)
+	go func() {
+		defer w.millWg.Done()
+		w.millMu.Lock()
+		defer w.millMu.Unlock()
+		if err := w.mill(now); err != nil {
+			fmt.Fprintf(os.Stderr, "log: clean up rotated files of %s failed: %v\n", w.Filename, err)
+		}
+	}()
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+func (w *RotateWriter) mill(now time.Time) error {
+	dir, prefix, ext := w.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		stamp = strings.TrimPrefix(stamp, prefix)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), time: t})
+	}
+	// 新的在前
+	sort.SliceStable(backups, func(i, j int) bool { return backups[i].time.After(backups[j].time) })
+
+	var errs []string
+	cutoff := now.Add(-w.MaxAge)
+	for i, b := range backups {
+		if (w.MaxBackups > 0 && i >= w.MaxBackups) || (w.MaxAge > 0 && b.time.Before(cutoff)) {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err.Error())
+			}
+			continue
+		}
+		if w.Compress && !strings.HasSuffix(b.path, ".gz") {
+			if err := gzipFile(b.path); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// gzipFile compresses src to src.gz, then removes src
+func gzipFile(src string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := src + ".gz.tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, src+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestRotateWriterSize(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	w := &RotateWriter{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxSize:    10,
+		MaxBackups: 2,
+		Compress:   true,
+		now:        func() time.Time { return now },
+	}
+
+	for _, line := range []string{"line-001\n", "line-002\n", "line-003\n", "line-004\n"} {
+		_, err := w.Write([]byte(line))
+		require.NoError(t, err)
+		now = now.Add(time.Second)
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"app-20240501T100002.000.log.gz",
+		"app-20240501T100003.000.log.gz",
+		"app.log",
+	}, listDir(t, dir))
+	assert.Equal(t, "line-004\n", readFile(t, filepath.Join(dir, "app.log")))
+
+	// 关闭后不再重新打开文件
+	require.NoError(t, os.Remove(filepath.Join(dir, "app.log")))
+	_, err := w.Write([]byte("line-005\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+	assert.ErrorIs(t, w.Rotate(), os.ErrClosed)
+	assert.ErrorIs(t, w.Reopen(), os.ErrClosed)
+	assert.NoFileExists(t, filepath.Join(dir, "app.log"))
+
+	f, err := os.Open(filepath.Join(dir, "app-20240501T100003.000.log.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "line-003\n", string(data))
+}
+
+func TestRotateWriterInterval(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 1, 23, 59, 0, 0, time.Local)
+	w := &RotateWriter{
+		Filename: filepath.Join(dir, "app.log"),
+		Interval: time.Hour,
+		MaxAge:   90 * time.Minute,
+		now:      func() time.Time { return now },
+	}
+	defer w.Close()
+
+	write := func(s string) {
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	write("a\n")
+	write("b\n")
+	now = now.Add(2 * time.Minute)
+	write("c\n")
+	now = now.Add(time.Hour)
+	write("d\n")
+	now = now.Add(time.Hour)
+	write("e\n")
+	require.NoError(t, w.Close())
+
+	// 超过 MaxAge 的备份被删除
+	assert.Equal(t, []string{"app-20240502T010100.000.log", "app-20240502T020100.000.log", "app.log"}, listDir(t, dir))
+	assert.Equal(t, "c\n", readFile(t, filepath.Join(dir, "app-20240502T010100.000.log")))
+	assert.Equal(t, "d\n", readFile(t, filepath.Join(dir, "app-20240502T020100.000.log")))
+	assert.Equal(t, "e\n", readFile(t, filepath.Join(dir, "app.log")))
+}
+
+func TestConfigRotate(t *testing.T) {
+	dir := t.TempDir()
+	logger := New()
+	cfg := &Config{
+		Output: filepath.Join(dir, "logs", "app.log"),
+		Rotate: &RotateConfig{MaxSizeMB: 1, Interval: "24h", MaxBackups: 3, MaxAge: "168h"},
+	}
+	require.NoError(t, cfg.ApplyTo(logger))
+	w, ok := logger.Out.(*RotateWriter)
+	require.True(t, ok)
+	assert.Equal(t, int64(1<<20), w.MaxSize)
+	assert.Equal(t, 24*time.Hour, w.Interval)
+	assert.Equal(t, 168*time.Hour, w.MaxAge)
+
+	logger.Info("hello")
+	assert.True(t, strings.Contains(readFile(t, cfg.Output), "hello"))
+	require.NoError(t, w.Close())
+
+	assert.Error(t, (&Config{Output: cfg.Output, Rotate: &RotateConfig{Interval: "1d"}}).ApplyTo(logger))
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	w := &RotateWriter{Filename: filename}
+	defer w.Close()
+
+	stop := w.ReopenOnSignal()
+	defer stop()
+	assert.True(t, SignalHandled(syscall.SIGHUP))
+
+	_, err := w.Write([]byte("before\n"))
+	require.NoError(t, err)
+	// 模拟 logrotate 移走文件
+	require.NoError(t, os.Rename(filename, filename+".1"))
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filename)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = w.Write([]byte("after\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "before\n", readFile(t, filename+".1"))
+	assert.Equal(t, "after\n", readFile(t, filename))
+
+	stop()
+	assert.False(t, SignalHandled(syscall.SIGHUP))
+}
@@ -0,0 +1,234 @@
+package log
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// number of counters per level, messages are hashed into them like zap's sampler
+const sampleCounters = 4096
+
+// maxDroppedKeys bounds the distinct messages tracked between reports,
+// drops of other messages are counted under an empty message.
+const maxDroppedKeys = 256
+
+// Sampling 采样规则: 每个周期内同一条消息前 First 条都输出, 之后每 Thereafter 条输出一条,
+// Thereafter 为 0 时丢弃剩余的. First 和 Thereafter 都为 0 时该 level 不采样
+type Sampling struct {
+	First      int `json:"first" yaml:"first" toml:"first"`
+	Thereafter int `json:"thereafter" yaml:"thereafter" toml:"thereafter"`
+}
+
+// Dropped count of dropped entries with the same level and message
+type Dropped struct {
+	Level   Level
+	Message string
+	Count   uint64
+}
+
+// Sampler 限制热点日志的输出, 按 level + message 计数, 只对配置了规则的 level 采样.
+// Hooks still see every entry, only the output is sampled, see SetSampler.
+type Sampler struct {
+	interval time.Duration
+	rules    map[Level]Sampling
+	counters map[Level]*[sampleCounters]sampleCounter
+
+	mu      sync.Mutex
+	dropped map[droppedKey]uint64
+
+	// now for testing
+	now func() time.Time
+}
+
+type droppedKey struct {
+	level Level
+	msg   string
+}
+
+// NewSampler return a sampler counting messages per interval with rules of levels
+//
+//	s := log.NewSampler(time.Second, map[log.Level]log.Sampling{
+//		log.InfoLevel:  {First: 100, Thereafter: 100},
+//		log.DebugLevel: {First: 10},
+//	})
+//	log.SetSampler(log.StandardLogger(), s)
+func NewSampler(interval time.Duration, rules map[Level]Sampling) *Sampler {
+	s := &Sampler{
+		interval: interval,
+		rules:    make(map[Level]Sampling, len(rules)),
+		counters: make(map[Level]*[sampleCounters]sampleCounter, len(rules)),
+		dropped:  make(map[droppedKey]uint64),
+	}
+	for level, rule := range rules {
+		if rule.First <= 0 && rule.Thereafter <= 0 {
+			continue
+		}
+		s.rules[level] = rule
+		s.counters[level] = new([sampleCounters]sampleCounter)
+	}
+	return s
+}
+
+// Sample reports whether entry should be written, it is counted as dropped otherwise
+func (s *Sampler) Sample(entry *Entry) bool {
+	rule, ok := s.rules[entry.Level]
+	if !ok {
+		return true
+	}
+
+	counter := &s.counters[entry.Level][hashMessage(entry.Message)%sampleCounters]
+	n := counter.inc(s.timeNow(), s.interval)
+	if n <= uint64(rule.First) || (rule.Thereafter > 0 && (n-uint64(rule.First))%uint64(rule.Thereafter) == 0) {
+		return true
+	}
+
+	s.drop(entry.Level, entry.Message)
+	return false
+}
+
+func (s *Sampler) drop(level Level, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := droppedKey{level, msg}
+	if _, ok := s.dropped[key]; !ok && len(s.dropped) >= maxDroppedKeys {
+		key.msg = ""
+	}
+	s.dropped[key]++
+}
+
+// Dropped return entries dropped since the last call, ordered by count, level and message
+func (s *Sampler) Dropped() []Dropped {
+	s.mu.Lock()
+	dropped := s.dropped
+	s.dropped = make(map[droppedKey]uint64)
+	s.mu.Unlock()
+
+	list := make([]Dropped, 0, len(dropped))
+	for key, count := range dropped {
+		list = append(list, Dropped{Level: key.level, Message: key.msg, Count: count})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		if list[i].Level != list[j].Level {
+			return list[i].Level < list[j].Level
+		}
+		return list[i].Message < list[j].Message
+	})
+	return list
+}
+
+// ReportDropped calls report with dropped entries every interval if any,
+// a nil report logs them as warnings to the standard logger. Call the returned function to stop.
+func (s *Sampler) ReportDropped(every time.Duration, report func([]Dropped)) (stop func()) {
+	if report == nil {
+		report = logDropped(StandardLogger())
+	}
+
+	done := make(chan struct{})
+	ticker := time.NewTicker(every)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if dropped := s.Dropped(); len(dropped) != 0 {
+					report(dropped)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// logDropped logs dropped counts, the reports are not sampled themselves
+func logDropped(logger *Logger) func([]Dropped) {
+	ctx := context.WithValue(context.Background(), noSampleKey, true)
+	return func(dropped []Dropped) {
+		for _, d := range dropped {
+			logger.WithContext(ctx).WithFields(Fields{
+				"level_dropped": d.Level.String(),
+				"msg_dropped":   d.Message,
+				"dropped":       d.Count,
+			}).Warn("log: entries dropped by sampling")
+		}
+	}
+}
+
+func (s *Sampler) timeNow() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func hashMessage(msg string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(msg))
+	return h.Sum32()
+}
+
+type sampleCounter struct {
+	resetAt int64
+	n       uint64
+}
+
+// inc increases the counter, resets it when the interval is over
+func (c *sampleCounter) inc(t time.Time, interval time.Duration) uint64 {
+	now := t.UnixNano()
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	if resetAt > now {
+		return atomic.AddUint64(&c.n, 1)
+	}
+
+	atomic.StoreUint64(&c.n, 1)
+	if !atomic.CompareAndSwapInt64(&c.resetAt, resetAt, now+interval.Nanoseconds()) {
+		// 其他 goroutine 已经重置
+		return atomic.AddUint64(&c.n, 1)
+	}
+	return 1
+}
+
+// samplingFormatter writes nothing for entries dropped by the sampler,
+// logrus has no way to drop an entry in hooks
+type samplingFormatter struct {
+	logrus.Formatter
+	sampler *Sampler
+}
+
+func (f *samplingFormatter) Format(entry *Entry) ([]byte, error) {
+	if entry.Context != nil && entry.Context.Value(noSampleKey) != nil {
+		return f.Formatter.Format(entry)
+	}
+	if !f.sampler.Sample(entry) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
+
+// SetSampler samples output of logger by s, nil disables sampling.
+// Set it after the formatter, SetFormatter replaces the sampler.
+func SetSampler(logger *Logger, s *Sampler) {
+	formatter := logger.Formatter
+	if f, ok := formatter.(*samplingFormatter); ok {
+		formatter = f.Formatter
+	}
+	if s != nil {
+		formatter = &samplingFormatter{Formatter: formatter, sampler: s}
+	}
+	logger.SetFormatter(formatter)
+	if logger == StandardLogger() {
+		syncNamed()
+	}
+}
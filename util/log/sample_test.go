@@ -0,0 +1,128 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	s := NewSampler(time.Second, map[Level]Sampling{
+		InfoLevel:  {First: 2, Thereafter: 3},
+		DebugLevel: {First: 1},
+		WarnLevel:  {},
+	})
+	s.now = func() time.Time { return now }
+
+	sample := func(level Level, msg string, times int) (kept int) {
+		for i := 0; i < times; i++ {
+			if s.Sample(&Entry{Level: level, Message: msg}) {
+				kept++
+			}
+		}
+		return kept
+	}
+
+	// 1, 2, 5, 8
+	assert.Equal(t, 4, sample(InfoLevel, "hot", 8))
+	assert.Equal(t, 1, sample(InfoLevel, "other", 1))
+	assert.Equal(t, 1, sample(DebugLevel, "hot", 5))
+	assert.Equal(t, 10, sample(ErrorLevel, "hot", 10), "levels without rule are not sampled")
+	assert.Equal(t, 10, sample(WarnLevel, "hot", 10), "zero rule is ignored")
+
+	now = now.Add(time.Second)
+	assert.Equal(t, 2, sample(InfoLevel, "hot", 2), "counters are reset after the interval")
+
+	assert.Equal(t, []Dropped{
+		{Level: InfoLevel, Message: "hot", Count: 4},
+		{Level: DebugLevel, Message: "hot", Count: 4},
+	}, s.Dropped())
+	assert.Empty(t, s.Dropped())
+}
+
+func TestSetSampler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+	s := NewSampler(time.Hour, map[Level]Sampling{InfoLevel: {First: 1}})
+	SetSampler(logger, s)
+	SetSampler(logger, s)
+	for i := 0; i < 3; i++ {
+		logger.Info("hot")
+	}
+	logger.Warn("warn")
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+
+	reported := make(chan []Dropped, 1)
+	stop := s.ReportDropped(10*time.Millisecond, func(dropped []Dropped) { reported <- dropped })
+	defer stop()
+	select {
+	case dropped := <-reported:
+		assert.Equal(t, []Dropped{{Level: InfoLevel, Message: "hot", Count: 2}}, dropped)
+	case <-time.After(time.Second):
+		t.Fatal("dropped entries are not reported")
+	}
+
+	SetSampler(logger, nil)
+	_, ok := logger.Formatter.(*logrus.TextFormatter)
+	assert.True(t, ok)
+}
+
+// syncBuffer buffer safe to read while logging in other goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestConfigSampling(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := New()
+	cfg := &Config{
+		Level: "debug",
+		Sampling: &SamplingConfig{
+			First: 1,
+			// error 不采样, warn 的丢弃报告本身不被采样
+			Levels:         map[string]Sampling{"warn": {Thereafter: 1000}, "error": {}},
+			ReportInterval: "10ms",
+		},
+	}
+	require.NoError(t, cfg.ApplyTo(logger))
+	defer (&Config{}).ApplyTo(logger)
+	logger.SetOutput(buf)
+
+	for i := 0; i < 3; i++ {
+		logger.Info("info")
+		logger.Warn("warn")
+		logger.Error("error")
+	}
+	assert.Equal(t, 1, strings.Count(buf.String(), `"msg":"info"`))
+	assert.Equal(t, 0, strings.Count(buf.String(), `"msg":"warn"`))
+	assert.Equal(t, 3, strings.Count(buf.String(), `"msg":"error"`))
+	assert.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), `"msg_dropped":"warn"`)
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Error(t, (&Config{Sampling: &SamplingConfig{Interval: "1d"}}).ApplyTo(logger))
+	assert.Error(t, (&Config{Sampling: &SamplingConfig{Levels: map[string]Sampling{"verbose": {}}}}).ApplyTo(logger))
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+var signals struct {
+	mu      sync.Mutex
+	handled map[os.Signal]int
+}
+
+// SignalHandled reports whether sig is handled by the log package
+// (ToggleDebugOnSignal, RotateWriter.ReopenOnSignal), svcutil.WaitSignals doesn't exit on it then.
+func SignalHandled(sig os.Signal) bool {
+	signals.mu.Lock()
+	defer signals.mu.Unlock()
+	return signals.handled[sig] > 0
+}
+
+// notifySignal calls fn on sig until stop is called
+func notifySignal(sig os.Signal, fn func()) (stop func()) {
+	signals.mu.Lock()
+	if signals.handled == nil {
+		signals.handled = make(map[os.Signal]int)
+	}
+	signals.handled[sig]++
+	signals.mu.Unlock()
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				fn()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+
+			signals.mu.Lock()
+			signals.handled[sig]--
+			signals.mu.Unlock()
+		})
+	}
+}
@@ -0,0 +1,194 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SlogHandler slog.Handler writing records to a logrus logger, so code using slog shares
+// the formatter, hooks and context fields (see Ctx) of util/log:
+//
+//	slog.SetDefault(slog.New(log.NewSlogHandler(nil)))
+//	slog.InfoContext(ctx, "order created", "order_id", 42)
+//
+// Groups are flattened into dotted field names, e.g. req.method.
+// Callers reported by Logger.ReportCaller are frames of the handler, not of slog callers.
+type SlogHandler struct {
+	logger *Logger
+	fields Fields
+	prefix string
+}
+
+// NewSlogHandler return a handler writing to logger, nil for the standard logger
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+func (h *SlogHandler) target() *Logger {
+	if h.logger != nil {
+		return h.logger
+	}
+	return StandardLogger()
+}
+
+// Enabled implements slog.Handler
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.target().IsLevelEnabled(fromSlogLevel(level))
+}
+
+// Handle implements slog.Handler
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	entry := Ctx(ctx).Dup()
+	entry.Logger = h.target()
+	for key, value := range h.fields {
+		entry.Data[key] = value
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		addAttr(entry.Data, h.prefix, attr)
+		return true
+	})
+	if !r.Time.IsZero() {
+		entry.Time = r.Time
+	}
+
+	entry.Log(fromSlogLevel(r.Level), r.Message)
+	return nil
+}
+
+// WithAttrs implements slog.Handler
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	fields := make(Fields, len(h.fields)+len(attrs))
+	for key, value := range h.fields {
+		fields[key] = value
+	}
+	for _, attr := range attrs {
+		addAttr(fields, h.prefix, attr)
+	}
+	return &SlogHandler{logger: h.logger, fields: fields, prefix: h.prefix}
+}
+
+// WithGroup implements slog.Handler
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+// addAttr adds attr to fields, groups are flattened
+func addAttr(fields Fields, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		// 匿名 group 的字段直接展开
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, a := range attr.Value.Group() {
+			addAttr(fields, prefix, a)
+		}
+		return
+	}
+	fields[prefix+attr.Key] = attr.Value.Any()
+}
+
+func fromSlogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return TraceLevel
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
+
+func toSlogLevel(level Level) slog.Level {
+	switch level {
+	case TraceLevel:
+		return slog.LevelDebug - 4
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	default:
+		// panic and fatal
+		return slog.LevelError + 4
+	}
+}
+
+// SlogHook logrus hook emitting entries to a slog.Handler, e.g. to ship logs by
+// a slog-based exporter. Don't emit to a SlogHandler of the same logger, it loops.
+//
+//	log.AddHook(log.NewSlogHook(otelHandler))
+type SlogHook struct {
+	handler slog.Handler
+	levels  []Level
+}
+
+// NewSlogHook return a hook emitting entries of levels (all levels if empty) to handler
+func NewSlogHook(handler slog.Handler, levels ...Level) *SlogHook {
+	if len(levels) == 0 {
+		levels = AllLevels
+	}
+	return &SlogHook{handler: handler, levels: levels}
+}
+
+// Levels implements logrus.Hook
+func (h *SlogHook) Levels() []Level {
+	return h.levels
+}
+
+// Fire implements logrus.Hook
+func (h *SlogHook) Fire(entry *Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := toSlogLevel(entry.Level)
+	if !h.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	var pc uintptr
+	if entry.Caller != nil {
+		pc = entry.Caller.PC
+	}
+	r := slog.NewRecord(entry.Time, level, entry.Message, pc)
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		r.AddAttrs(slog.Any(key, entry.Data[key]))
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+var _ logrus.Hook = (*SlogHook)(nil)
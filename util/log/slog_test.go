@@ -0,0 +1,65 @@
+//go:build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(InfoLevel)
+
+	ctx := ToContextFields(WithRequestID(context.Background(), "req-1"), Fields{"user": "alice"})
+	l := slog.New(NewSlogHandler(logger)).With("service", "order").WithGroup("req")
+	l.DebugContext(ctx, "hidden")
+	l.WarnContext(ctx, "slow", "method", "GET", slog.Group("db", "rows", 3), "err", errors.New("timeout"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	entry := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "warning", entry["level"])
+	assert.Equal(t, "slow", entry["msg"])
+	assert.Equal(t, "order", entry["service"])
+	assert.Equal(t, "GET", entry["req.method"])
+	assert.Equal(t, float64(3), entry["req.db.rows"])
+	assert.Equal(t, "timeout", entry["req.err"])
+	assert.Equal(t, "alice", entry["user"])
+	assert.Equal(t, "req-1", entry[FieldRequestID])
+
+	assert.Equal(t, TraceLevel, fromSlogLevel(slog.LevelDebug-1))
+	assert.Equal(t, ErrorLevel, fromSlogLevel(slog.LevelError+4))
+}
+
+func TestSlogHook(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New()
+	logger.SetOutput(&bytes.Buffer{})
+	logger.AddHook(NewSlogHook(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	logger.Debug("hidden")
+	logger.WithFields(Fields{"order_id": 42, "paid": true}).Error("failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	entry := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, "failed", entry["msg"])
+	assert.Equal(t, float64(42), entry["order_id"])
+	assert.Equal(t, true, entry["paid"])
+}
@@ -0,0 +1,216 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Headers of request and trace ids
+const (
+	RequestIDHeader   = "X-Request-Id"
+	TraceparentHeader = "traceparent"
+)
+
+// Fields added by Ctx
+const (
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldSpanID    = "span_id"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	traceKey
+	// noSampleKey marks entries written regardless of the sampler
+	noSampleKey
+)
+
+// TraceContext W3C trace context, see https://www.w3.org/TR/trace-context/
+type TraceContext struct {
+	// TraceID 32 hex characters, shared by all spans of a trace
+	TraceID string
+	// SpanID 16 hex characters, id of the current span
+	SpanID string
+	// ParentID span id of the caller, empty for a root span
+	ParentID string
+	// Sampled the sampled flag propagated to downstream services
+	Sampled bool
+}
+
+// NewTrace starts a new trace with a root span
+func NewTrace() TraceContext {
+	return TraceContext{TraceID: randomHex(16), SpanID: randomHex(8), Sampled: true}
+}
+
+// Child return a new span of the same trace, tc is its parent
+func (tc TraceContext) Child() TraceContext {
+	return TraceContext{TraceID: tc.TraceID, SpanID: randomHex(8), ParentID: tc.SpanID, Sampled: tc.Sampled}
+}
+
+// Traceparent return the traceparent header value of the span, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func (tc TraceContext) Traceparent() string {
+	flags := "00"
+	if tc.Sampled {
+		flags = "01"
+	}
+	return "00-" + tc.TraceID + "-" + tc.SpanID + "-" + flags
+}
+
+// ParseTraceparent parses traceparent header value, the span id of the header is
+// the span of the caller.
+func ParseTraceparent(header string) (TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return TraceContext{}, fmt.Errorf("log: invalid traceparent %q", header)
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	// version ff 非法, 未知的更高版本按 00 的格式解析前四段
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) ||
+		!isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) ||
+		traceID == strings.Repeat("0", 32) || spanID == strings.Repeat("0", 16) {
+		return TraceContext{}, fmt.Errorf("log: invalid traceparent %q", header)
+	}
+
+	flag, _ := hex.DecodeString(flags)
+	return TraceContext{TraceID: traceID, SpanID: spanID, Sampled: flag[0]&1 == 1}, nil
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// NewRequestID return a random request id
+func NewRequestID() string {
+	return randomHex(16)
+}
+
+// maxRequestIDLen max length of request ids accepted from clients
+const maxRequestIDLen = 64
+
+// ValidRequestID reports whether id is safe to log and echo back:
+// 1 to 64 characters of [A-Za-z0-9-_.]
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDFromHeader return the X-Request-Id of h if valid, otherwise a new request id
+func RequestIDFromHeader(h http.Header) string {
+	if id := h.Get(RequestIDHeader); ValidRequestID(id) {
+		return id
+	}
+	return NewRequestID()
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID return a copy of ctx carrying the request id, it is added to entries by Ctx
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext return the request id carried by ctx, empty if none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithTrace return a copy of ctx carrying the trace context, trace_id and span_id
+// are added to entries by Ctx
+func WithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey, tc)
+}
+
+// TraceFromContext return the trace context carried by ctx
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey).(TraceContext)
+	return tc, ok
+}
+
+// FromHeader extracts ids of an incoming request into ctx: the request id of X-Request-Id
+// (generated if missing or invalid, see ValidRequestID), and a child span of the traceparent (a new trace if missing or invalid).
+func FromHeader(ctx context.Context, h http.Header) context.Context {
+	requestID := RequestIDFromHeader(h)
+
+	tc := NewTrace()
+	if parent, err := ParseTraceparent(h.Get(TraceparentHeader)); err == nil {
+		tc = parent.Child()
+	}
+	return WithTrace(WithRequestID(ctx, requestID), tc)
+}
+
+// InjectHeader sets ids carried by ctx to headers of an outgoing request,
+// so logs of the downstream service can be correlated.
+//
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	log.InjectHeader(ctx, req.Header)
+func InjectHeader(ctx context.Context, h http.Header) {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		h.Set(RequestIDHeader, requestID)
+	}
+	if tc, ok := TraceFromContext(ctx); ok {
+		h.Set(TraceparentHeader, tc.Traceparent())
+	}
+}
+
+// TraceMiddleware seeds request and trace ids into the request context by FromHeader,
+// the request id is also set to the response header.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := FromHeader(r.Context(), r.Header)
+		w.Header().Set(RequestIDHeader, RequestIDFromContext(ctx))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// traceFields return ids carried by ctx which are not in data
+func traceFields(ctx context.Context, data Fields) Fields {
+	var fields Fields
+	add := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if _, ok := data[key]; ok {
+			return
+		}
+		if fields == nil {
+			fields = make(Fields, 3)
+		}
+		fields[key] = value
+	}
+
+	add(FieldRequestID, RequestIDFromContext(ctx))
+	if tc, ok := TraceFromContext(ctx); ok {
+		add(FieldTraceID, tc.TraceID)
+		add(FieldSpanID, tc.SpanID)
+	}
+	return fields
+}
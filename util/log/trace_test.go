@@ -0,0 +1,114 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, TraceContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+		Sampled: true,
+	}, tc)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", tc.Traceparent())
+
+	// 未知的更高版本兼容解析
+	tc, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	require.NoError(t, err)
+	assert.False(t, tc.Sampled)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902-01",
+	} {
+		_, err := ParseTraceparent(header)
+		assert.Error(t, err, header)
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	var ctx context.Context
+	handler := TraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	r.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, "req-1", RequestIDFromContext(ctx))
+	assert.Equal(t, "req-1", w.Header().Get(RequestIDHeader))
+	tc, ok := TraceFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", tc.ParentID)
+	assert.Len(t, tc.SpanID, 16)
+	assert.NotEqual(t, tc.ParentID, tc.SpanID)
+
+	out := http.Header{}
+	InjectHeader(ctx, out)
+	assert.Equal(t, "req-1", out.Get(RequestIDHeader))
+	assert.Equal(t, tc.Traceparent(), out.Get(TraceparentHeader))
+
+	// 没有 id 时生成新的
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(TraceparentHeader, "invalid")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Len(t, RequestIDFromContext(ctx), 32)
+	assert.Equal(t, RequestIDFromContext(ctx), w.Header().Get(RequestIDHeader))
+	assert.Empty(t, r.Header.Get(RequestIDHeader), "request header is not changed")
+
+	// 不合法的 id 重新生成
+	for _, id := range []string{"bad id", "id\n{\"level\":\"error\"}", strings.Repeat("a", 65)} {
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(RequestIDHeader, id)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Len(t, RequestIDFromContext(ctx), 32, id)
+	}
+	assert.True(t, ValidRequestID("Req-1_a.b"))
+	tc, ok = TraceFromContext(ctx)
+	require.True(t, ok)
+	assert.Len(t, tc.TraceID, 32)
+	assert.Empty(t, tc.ParentID)
+}
+
+func TestCtxTraceFields(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, Ctx(ctx).Data)
+
+	tc := NewTrace()
+	ctx = WithTrace(WithRequestID(ctx, "req-1"), tc)
+	assert.Equal(t, Fields{
+		FieldRequestID: "req-1",
+		FieldTraceID:   tc.TraceID,
+		FieldSpanID:    tc.SpanID,
+	}, Ctx(ctx).Data)
+
+	// ids are added to entries stored by ToContextFields, explicit fields win
+	ctx = ToContextFields(context.Background(), Fields{"user": 1, FieldRequestID: "explicit"})
+	ctx = WithTrace(WithRequestID(ctx, "req-2"), tc)
+	assert.Equal(t, Fields{
+		"user":         1,
+		FieldRequestID: "explicit",
+		FieldTraceID:   tc.TraceID,
+		FieldSpanID:    tc.SpanID,
+	}, Ctx(ctx).Data)
+}
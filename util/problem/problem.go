@@ -0,0 +1,115 @@
+// Package problem renders errors as RFC 7807 problem details JSON responses,
+// so all services return the same error shape:
+//
+//	{
+//	  "type": "about:blank",
+//	  "title": "Not Found",
+//	  "status": 404,
+//	  "detail": "order 42 not found",
+//	  "code": "ORDER_NOT_FOUND",
+//	  "request_id": "4bf92f3577b34da6a3ce929d0e0e4736"
+//	}
+//
+// Details of business errors (xerr.CustomError) are localized by the request's
+// Accept-Language, details of other errors are hidden and reported by xerr.ReportError.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/zhaolion/gostack/util/log"
+	"github.com/zhaolion/gostack/util/xerr"
+)
+
+// ContentType media type of problem details
+const ContentType = "application/problem+json"
+
+// RequestIDHeader header of the request id
+const RequestIDHeader = log.RequestIDHeader
+
+// TypeBaseURI is prefixed to the error code as the problem type, e.g. "https://errors.example.com/",
+// "about:blank" is used if it is empty.
+var TypeBaseURI = ""
+
+// Problem RFC 7807 problem details
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Code      string `json:"code,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// FromError builds problem details of err for request r, err is not reported
+func FromError(r *http.Request, err error) *Problem {
+	status := xerr.HTTPStatus(err)
+	p := &Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Code:      string(xerr.CategoryOf(err)),
+		RequestID: log.RequestIDFromContext(r.Context()),
+	}
+	if id := r.Header.Get(RequestIDHeader); p.RequestID == "" && log.ValidRequestID(id) {
+		p.RequestID = id
+	}
+
+	// 只有业务错误才把错误信息返回给调用方
+	if _, ok := xerr.IsCustomError(err); ok {
+		// 只用业务错误本身的信息, 外层 wrap 的信息和调用位置不返回
+		p.Detail = xerr.LocalizeRequest(customCause(err), r)
+		if code := xerr.CodeOf(err); code != "" {
+			p.Code = code
+		}
+	}
+	if TypeBaseURI != "" && p.Code != "" {
+		p.Type = TypeBaseURI + p.Code
+	}
+	return p
+}
+
+// customCause return the CustomError or CodedError in the chain of err
+func customCause(err error) error {
+	var custom *xerr.CustomError
+	if xerr.As(err, &custom) {
+		return custom
+	}
+	var coded *xerr.CodedError
+	if xerr.As(err, &coded) {
+		return coded
+	}
+	return err
+}
+
+// Write writes the problem as response
+func (p *Problem) Write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// Error writes err as problem details response, errors except CustomError
+// are reported by xerr.ReportError with the request id. Nothing is written if err is nil.
+//
+//	if err := svc.CreateOrder(r.Context(), req); err != nil {
+//		problem.Error(w, r, err)
+//		return
+//	}
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+
+	p := FromError(r, err)
+	ctx := log.ToContextFields(r.Context(), log.Fields{
+		"request_id": p.RequestID,
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"status":     p.Status,
+	})
+	_ = xerr.ReportError(ctx, err)
+
+	p.Write(w)
+}
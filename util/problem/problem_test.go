@@ -0,0 +1,85 @@
+package problem
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhaolion/gostack/util/log"
+	"github.com/zhaolion/gostack/util/xerr"
+)
+
+func serve(t *testing.T, err error, lang string) (*httptest.ResponseRecorder, *Problem) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	r.Header.Set("Accept-Language", lang)
+	Error(w, r, err)
+
+	p := &Problem{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), p))
+	return w, p
+}
+
+func TestError(t *testing.T) {
+	defer xerr.SetMessageCatalog(xerr.MessageCatalog())
+	catalog := xerr.NewCatalog("en")
+	catalog.Add("zh", map[string]string{"ORDER_NOT_FOUND": "订单 {id} 不存在"})
+	xerr.SetMessageCatalog(catalog)
+
+	buf := new(bytes.Buffer)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stdout)
+
+	w, p := serve(t, xerr.Coded(xerr.CategoryNotFound, "ORDER_NOT_FOUND", "order 42 not found"), "en")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, &Problem{
+		Type:      "about:blank",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Detail:    "order 42 not found",
+		Code:      "ORDER_NOT_FOUND",
+		RequestID: "req-1",
+	}, p)
+
+	_, p = serve(t, xerr.Localized("ORDER_NOT_FOUND", xerr.Params{"id": 42}), "zh-CN")
+	assert.Equal(t, http.StatusBadRequest, p.Status)
+	assert.Equal(t, "订单 42 不存在", p.Detail)
+	assert.Equal(t, string(xerr.CategoryInvalidArgument), p.Code)
+	assert.Empty(t, buf.String(), "custom errors are not reported")
+
+	// wrap 的信息和调用位置不返回给调用方
+	_, p = serve(t, xerr.Wrap(xerr.WithMessage(xerr.Custom("stock not enough")), "insert order into orders table"), "en")
+	assert.Equal(t, "stock not enough", p.Detail)
+	_, p = serve(t, xerr.WithMessage(xerr.Coded(xerr.CategoryConflict, "ORDER_PAID", "order 42 already paid"), "pay order"), "en")
+	assert.Equal(t, "order 42 already paid", p.Detail)
+	assert.Equal(t, "ORDER_PAID", p.Code)
+
+	// 非业务错误隐藏细节, 并上报
+	w, p = serve(t, xerr.Wrap(io.EOF, "read secret.key"), "en")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, p.Detail)
+	assert.Equal(t, string(xerr.CategoryInternal), p.Code)
+	assert.NotContains(t, w.Body.String(), "secret.key")
+	assert.Contains(t, buf.String(), "secret.key")
+	assert.Contains(t, buf.String(), `"request_id":"req-1"`)
+
+	TypeBaseURI = "https://errors.example.com/"
+	defer func() { TypeBaseURI = "" }()
+	_, p = serve(t, xerr.Coded(xerr.CategoryUnavailable, "UPSTREAM_DOWN", "payment gateway down"), "en")
+	assert.Equal(t, "https://errors.example.com/UNAVAILABLE", p.Type)
+	assert.Equal(t, http.StatusServiceUnavailable, p.Status)
+}
+
+func TestErrorNil(t *testing.T) {
+	w := httptest.NewRecorder()
+	Error(w, httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	assert.Equal(t, 0, w.Body.Len())
+}
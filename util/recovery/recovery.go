@@ -0,0 +1,194 @@
+// Package recovery recovers panics of HTTP handlers and goroutines,
+// panics are converted to xerr errors with the panicking stack, then
+// passed to waitutil.PanicHandlers and delivered to xerr reporters.
+package recovery
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/zhaolion/gostack/util/log"
+	"github.com/zhaolion/gostack/util/problem"
+	"github.com/zhaolion/gostack/util/waitutil"
+	"github.com/zhaolion/gostack/util/xerr"
+)
+
+// RequestIDHeader header of the request id, generated if the request doesn't carry a valid one
+const RequestIDHeader = log.RequestIDHeader
+
+// Handle handles a recovered panic value: calls waitutil.PanicHandlers,
+// then logs and reports it as xerr error. Returns the converted error.
+func Handle(ctx context.Context, r interface{}) error {
+	return handle(ctx, r, nil)
+}
+
+func handle(ctx context.Context, r interface{}, fields log.Fields) error {
+	err := xerr.WithFields(xerr.FromPanic(r), fields)
+	if err == nil {
+		return nil
+	}
+
+	for _, fn := range waitutil.PanicHandlers {
+		fn(r)
+	}
+	xerr.ReportPanic(ctx, err)
+	return err
+}
+
+// Recover recovers a panic and handles it without crashing, must be called directly by defer
+//
+//	defer recovery.Recover(ctx)
+func Recover(ctx context.Context) {
+	if r := recover(); r != nil {
+		Handle(ctx, r)
+	}
+}
+
+// SafeGo runs fn in a new goroutine, a panic of fn is handled instead of crashing the process
+func SafeGo(ctx context.Context, fn func(ctx context.Context)) {
+	go func() {
+		defer Recover(ctx)
+		fn(ctx)
+	}()
+}
+
+// Middleware recovers panics of next, responds 500 problem details with the request id,
+// so the response can be correlated with the reported error. The request id seeded by
+// log.TraceMiddleware is used if any.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := log.RequestIDFromContext(r.Context())
+		if requestID == "" {
+			requestID = log.RequestIDFromHeader(r.Header)
+			r = r.WithContext(log.WithRequestID(r.Context(), requestID))
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// 约定用于中断响应的 panic, 交给 net/http 处理
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			err := handle(r.Context(), rec, log.Fields{
+				"request_id": requestID,
+				"method":     r.Method,
+				"path":       r.URL.Path,
+			})
+
+			if rw.wroteHeader {
+				return
+			}
+			// 已经上报过, 只写响应
+			problem.FromError(r, err).Write(w)
+		}()
+
+		next.ServeHTTP(rw.wrap(), r)
+	})
+}
+
+// responseWriter tracks whether the response is started
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying writer, used by http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// wrap return w exposing only the optional interfaces (http.Flusher, http.Hijacker, http.Pusher)
+// the underlying writer implements, so type assertions of handlers stay truthful
+func (w *responseWriter) wrap() http.ResponseWriter {
+	_, canFlush := w.ResponseWriter.(http.Flusher)
+	_, canHijack := w.ResponseWriter.(http.Hijacker)
+	_, canPush := w.ResponseWriter.(http.Pusher)
+	f, h, p := flusher{w}, hijacker{w}, pusher{w}
+
+	switch {
+	case canFlush && canHijack && canPush:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, f, h, p}
+	case canFlush && canHijack:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+		}{w, f, h}
+	case canFlush && canPush:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Pusher
+		}{w, f, p}
+	case canHijack && canPush:
+		return struct {
+			*responseWriter
+			http.Hijacker
+			http.Pusher
+		}{w, h, p}
+	case canFlush:
+		return struct {
+			*responseWriter
+			http.Flusher
+		}{w, f}
+	case canHijack:
+		return struct {
+			*responseWriter
+			http.Hijacker
+		}{w, h}
+	case canPush:
+		return struct {
+			*responseWriter
+			http.Pusher
+		}{w, p}
+	}
+	return w
+}
+
+type flusher struct{ *responseWriter }
+
+func (w flusher) Flush() {
+	w.wroteHeader = true
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijacker struct{ *responseWriter }
+
+// Hijack e.g. for websocket upgrades
+func (w hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		// 连接已经接管, 不能再写 500
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+type pusher struct{ *responseWriter }
+
+func (w pusher) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
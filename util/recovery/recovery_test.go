@@ -0,0 +1,174 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhaolion/gostack/util/log"
+	"github.com/zhaolion/gostack/util/waitutil"
+	"github.com/zhaolion/gostack/util/xerr"
+)
+
+type memReporter struct {
+	mu      sync.Mutex
+	reports []*xerr.Report
+}
+
+func (m *memReporter) Report(ctx context.Context, r *xerr.Report) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reports = append(m.reports, r)
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	defer xerr.ResetReporters()
+	reporter := &memReporter{}
+	xerr.RegisterReporter(reporter)
+	handlers := waitutil.PanicHandlers
+	defer func() { waitutil.PanicHandlers = handlers }()
+	var values []interface{}
+	waitutil.PanicHandlers = []func(interface{}){func(r interface{}) { values = append(values, r) }}
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "req-1", w.Header().Get(RequestIDHeader))
+	body := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "req-1", body["request_id"])
+	assert.Equal(t, []interface{}{"boom"}, values)
+
+	require.True(t, xerr.FlushReporters(time.Second))
+	require.Len(t, reporter.reports, 1)
+	assert.True(t, reporter.reports[0].Panic)
+	assert.Equal(t, "req-1", reporter.reports[0].Fields["request_id"])
+	assert.Equal(t, "/panic", reporter.reports[0].Fields["path"])
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, w.Header().Get(RequestIDHeader), 32)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "bad\nid")
+	handler.ServeHTTP(w, r)
+	assert.Len(t, w.Header().Get(RequestIDHeader), 32, "invalid request id is replaced")
+	assert.Equal(t, "bad\nid", r.Header.Get(RequestIDHeader))
+
+	// request id seeded by log.TraceMiddleware
+	var requestID string
+	traced := log.TraceMiddleware(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = log.RequestIDFromContext(r.Context())
+	})))
+	w = httptest.NewRecorder()
+	traced.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, requestID, 32)
+	assert.Equal(t, requestID, w.Header().Get(RequestIDHeader))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestMiddlewarePanicLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stdout)
+
+	var traceID string
+	handler := log.TraceMiddleware(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc, _ := log.TraceFromContext(r.Context())
+		traceID = tc.TraceID
+		panic("boom")
+	})))
+	r := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	entry := map[string]interface{}{}
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		if bytes.Contains(line, []byte("panic happened")) {
+			require.NoError(t, json.Unmarshal(line, &entry))
+		}
+	}
+	assert.Equal(t, "req-1", entry[log.FieldRequestID])
+	assert.Equal(t, traceID, entry[log.FieldTraceID])
+	assert.NotEmpty(t, entry[log.FieldSpanID])
+}
+
+func TestMiddlewareHijack(t *testing.T) {
+	srv := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		_ = rw.Flush()
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hijacked", string(body))
+
+	// 只暴露底层 writer 实现了的接口
+	w := httptest.NewRecorder()
+	rw := &responseWriter{ResponseWriter: w}
+	wrapped := rw.wrap()
+	_, ok := wrapped.(http.Hijacker)
+	assert.False(t, ok)
+	_, ok = wrapped.(http.Pusher)
+	assert.False(t, ok)
+	require.Implements(t, (*http.Flusher)(nil), wrapped)
+	wrapped.(http.Flusher).Flush()
+	assert.True(t, rw.wroteHeader)
+	assert.True(t, w.Flushed)
+	assert.Equal(t, http.ResponseWriter(w), wrapped.(interface{ Unwrap() http.ResponseWriter }).Unwrap())
+
+	bare := &responseWriter{ResponseWriter: struct{ http.ResponseWriter }{w}}
+	assert.Equal(t, http.ResponseWriter(bare), bare.wrap())
+}
+
+func TestSafeGo(t *testing.T) {
+	handlers := waitutil.PanicHandlers
+	defer func() { waitutil.PanicHandlers = handlers }()
+	handled := make(chan interface{}, 1)
+	waitutil.PanicHandlers = []func(interface{}){func(r interface{}) { handled <- r }}
+
+	SafeGo(context.Background(), func(ctx context.Context) {
+		panic("boom")
+	})
+
+	select {
+	case r := <-handled:
+		assert.Equal(t, "boom", r)
+	case <-time.After(time.Second):
+		t.Fatal("panic is not handled")
+	}
+}
@@ -106,9 +106,48 @@ func WaitFor(addr string, f func(stop <-chan struct{}) error) {
 	quit <- struct{}{}
 }
 
+var handlers struct {
+	mu       sync.Mutex
+	patterns []string
+	handlers map[string]http.Handler
+}
+
+// HandleHTTP mounts handler at pattern next to the health check on the server
+// started by HTTPHealthCheck, must be called before it starts, e.g.
+//
+//	svcutil.HandleHTTP("/log/level", log.LevelHandler())
+func HandleHTTP(pattern string, handler http.Handler) {
+	handlers.mu.Lock()
+	defer handlers.mu.Unlock()
+	if handlers.handlers == nil {
+		handlers.handlers = make(map[string]http.Handler)
+	}
+	if _, ok := handlers.handlers[pattern]; !ok {
+		handlers.patterns = append(handlers.patterns, pattern)
+	}
+	handlers.handlers[pattern] = handler
+}
+
+func httpHandler() http.Handler {
+	handlers.mu.Lock()
+	defer handlers.mu.Unlock()
+
+	health := healthcheck.NewHandler()
+	if len(handlers.patterns) == 0 {
+		return health
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", health)
+	for _, pattern := range handlers.patterns {
+		mux.Handle(pattern, handlers.handlers[pattern])
+	}
+	return mux
+}
+
 // HTTPHealthCheck HTTP 模式健康检查，会阻塞执行
 func HTTPHealthCheck(addr string, stop <-chan struct{}) {
-	server := &http.Server{Addr: addr, Handler: healthcheck.NewHandler()}
+	server := &http.Server{Addr: addr, Handler: httpHandler()}
 	go func() {
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Errorf("[BgHealthCheck] health server close with err: %+v", err)
@@ -125,15 +164,21 @@ func HTTPHealthCheck(addr string, stop <-chan struct{}) {
 }
 
 // WaitSignals 监听退出信号
+// log 包处理的信号 (log.ToggleDebugOnSignal 的 SIGUSR1, RotateWriter.ReopenOnSignal 的 SIGHUP) 不再退出
 func WaitSignals() chan struct{} {
 	stop := make(chan struct{})
 
-	quit := make(chan os.Signal)
-	signal.Notify(quit, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2)
 
 	go func() {
-		<-quit
+		for sig := range quit {
+			if log.SignalHandled(sig) {
+				continue
+			}
+			break
+		}
+		signal.Stop(quit)
 		close(stop)
 	}()
 
@@ -0,0 +1,70 @@
+package waitutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/zhaolion/gostack/util/xerr"
+)
+
+// DefaultRetry is the recommended retry for a transient failure,
+// e.g. a dropped connection or a database deadlock.
+var DefaultRetry = Backoff{
+	Steps:    5,
+	Duration: 10 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// OnError calls fn with backoff while it returns an error retriable accepts,
+// up to backoff.Steps times. It returns nil on success, otherwise the last error of fn.
+func OnError(backoff Backoff, retriable func(error) bool, fn func() error) error {
+	return OnErrorWithContext(context.Background(), backoff, retriable, func(context.Context) error {
+		return fn()
+	})
+}
+
+// OnErrorWithContext is like OnError, but stops waiting when ctx is done,
+// the last error of fn (or ctx.Err() if fn was never called) is returned.
+func OnErrorWithContext(ctx context.Context, backoff Backoff, retriable func(error) bool, fn func(context.Context) error) error {
+	var lastErr error
+	for {
+		if err := ctx.Err(); err != nil {
+			if lastErr != nil {
+				return lastErr
+			}
+			return err
+		}
+
+		lastErr = fn(ctx)
+		if lastErr == nil || !retriable(lastErr) {
+			return lastErr
+		}
+		if backoff.Steps <= 1 {
+			return lastErr
+		}
+
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return lastErr
+		case <-timer.C:
+		}
+	}
+}
+
+// Retry calls fn with backoff while it returns a retryable error (see xerr.IsRetryable),
+// errors marked by xerr.Permanent or not classified as transient are returned immediately.
+//
+//	err := waitutil.Retry(waitutil.DefaultRetry, func() error {
+//		return db.Save(&order).Error
+//	})
+func Retry(backoff Backoff, fn func() error) error {
+	return OnError(backoff, xerr.IsRetryable, fn)
+}
+
+// RetryWithContext is like Retry, but stops waiting when ctx is done
+func RetryWithContext(ctx context.Context, backoff Backoff, fn func(context.Context) error) error {
+	return OnErrorWithContext(ctx, backoff, xerr.IsRetryable, fn)
+}
@@ -0,0 +1,62 @@
+package waitutil
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaolion/gostack/util/xerr"
+)
+
+func TestRetry(t *testing.T) {
+	backoff := Backoff{Steps: 3, Duration: time.Millisecond}
+
+	calls := 0
+	err := Retry(backoff, func() error {
+		calls++
+		if calls < 2 {
+			return driver.ErrBadConn
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// 重试次数耗尽, 返回最后一次的错误
+	calls = 0
+	err = Retry(backoff, func() error {
+		calls++
+		return xerr.Retryable(io.EOF)
+	})
+	assert.Equal(t, io.EOF, xerr.Cause(err))
+	assert.Equal(t, 3, calls)
+
+	// 非临时错误不重试
+	calls = 0
+	err = Retry(backoff, func() error {
+		calls++
+		return io.EOF
+	})
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := RetryWithContext(ctx, Backoff{Steps: 10, Duration: time.Hour}, func(context.Context) error {
+		calls++
+		cancel()
+		return driver.ErrBadConn
+	})
+	assert.Equal(t, driver.ErrBadConn, err)
+	assert.Equal(t, 1, calls)
+
+	assert.Equal(t, context.Canceled, RetryWithContext(ctx, DefaultRetry, func(context.Context) error {
+		t.Fatal("should not be called")
+		return nil
+	}))
+}
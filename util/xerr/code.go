@@ -0,0 +1,288 @@
+package xerr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Category 错误分类, 用于映射 HTTP status code 和 gRPC code
+type Category string
+
+const (
+	// CategoryUnknown unclassified error, treated as internal error
+	CategoryUnknown Category = "UNKNOWN"
+	// CategoryCanceled the operation was canceled by the caller
+	CategoryCanceled Category = "CANCELED"
+	// CategoryInvalidArgument the client specified an invalid argument
+	CategoryInvalidArgument Category = "INVALID_ARGUMENT"
+	// CategoryDeadlineExceeded deadline expired before the operation could complete
+	CategoryDeadlineExceeded Category = "DEADLINE_EXCEEDED"
+	// CategoryNotFound some requested entity was not found
+	CategoryNotFound Category = "NOT_FOUND"
+	// CategoryAlreadyExists the entity that a client attempted to create already exists
+	CategoryAlreadyExists Category = "ALREADY_EXISTS"
+	// CategoryConflict the operation conflicts with current state, e.g. concurrency conflict
+	CategoryConflict Category = "CONFLICT"
+	// CategoryPermissionDenied the caller does not have permission
+	CategoryPermissionDenied Category = "PERMISSION_DENIED"
+	// CategoryUnauthenticated the request does not have valid authentication credentials
+	CategoryUnauthenticated Category = "UNAUTHENTICATED"
+	// CategoryResourceExhausted some resource has been exhausted, e.g. rate limit
+	CategoryResourceExhausted Category = "RESOURCE_EXHAUSTED"
+	// CategoryFailedPrecondition the system is not in a state required for the operation
+	CategoryFailedPrecondition Category = "FAILED_PRECONDITION"
+	// CategoryUnimplemented the operation is not implemented or not supported
+	CategoryUnimplemented Category = "UNIMPLEMENTED"
+	// CategoryUnavailable the service is currently unavailable, usually transient
+	CategoryUnavailable Category = "UNAVAILABLE"
+	// CategoryInternal internal errors, something is very broken
+	CategoryInternal Category = "INTERNAL"
+)
+
+// gRPC codes, same values as google.golang.org/grpc/codes
+const (
+	grpcCanceled           uint32 = 1
+	grpcUnknown            uint32 = 2
+	grpcInvalidArgument    uint32 = 3
+	grpcDeadlineExceeded   uint32 = 4
+	grpcNotFound           uint32 = 5
+	grpcAlreadyExists      uint32 = 6
+	grpcPermissionDenied   uint32 = 7
+	grpcResourceExhausted  uint32 = 8
+	grpcFailedPrecondition uint32 = 9
+	grpcAborted            uint32 = 10
+	grpcUnimplemented      uint32 = 12
+	grpcInternal           uint32 = 13
+	grpcUnavailable        uint32 = 14
+	grpcUnauthenticated    uint32 = 16
+)
+
+// StatusClientClosedRequest nginx non-standard code for canceled request
+const StatusClientClosedRequest = 499
+
+// HTTPStatus return HTTP status code of the category
+func (c Category) HTTPStatus() int {
+	switch c {
+	case CategoryCanceled:
+		return StatusClientClosedRequest
+	case CategoryInvalidArgument:
+		return http.StatusBadRequest
+	case CategoryDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case CategoryNotFound:
+		return http.StatusNotFound
+	case CategoryAlreadyExists, CategoryConflict:
+		return http.StatusConflict
+	case CategoryPermissionDenied:
+		return http.StatusForbidden
+	case CategoryUnauthenticated:
+		return http.StatusUnauthorized
+	case CategoryResourceExhausted:
+		return http.StatusTooManyRequests
+	case CategoryFailedPrecondition:
+		return http.StatusPreconditionFailed
+	case CategoryUnimplemented:
+		return http.StatusNotImplemented
+	case CategoryUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GRPCCode return gRPC code of the category,
+// convert it by codes.Code(category.GRPCCode())
+func (c Category) GRPCCode() uint32 {
+	switch c {
+	case CategoryCanceled:
+		return grpcCanceled
+	case CategoryInvalidArgument:
+		return grpcInvalidArgument
+	case CategoryDeadlineExceeded:
+		return grpcDeadlineExceeded
+	case CategoryNotFound:
+		return grpcNotFound
+	case CategoryAlreadyExists:
+		return grpcAlreadyExists
+	case CategoryConflict:
+		return grpcAborted
+	case CategoryPermissionDenied:
+		return grpcPermissionDenied
+	case CategoryUnauthenticated:
+		return grpcUnauthenticated
+	case CategoryResourceExhausted:
+		return grpcResourceExhausted
+	case CategoryFailedPrecondition:
+		return grpcFailedPrecondition
+	case CategoryUnimplemented:
+		return grpcUnimplemented
+	case CategoryUnavailable:
+		return grpcUnavailable
+	case CategoryInternal:
+		return grpcInternal
+	default:
+		return grpcUnknown
+	}
+}
+
+// IsClientError 调用方引起的错误 (4xx), 属于业务错误, 不需要上报
+func (c Category) IsClientError() bool {
+	status := c.HTTPStatus()
+	return status >= 400 && status < 500
+}
+
+// Coded biz error with category and stable code
+func Coded(category Category, code string, msg string) error {
+	return wrapStack(&CodedError{category: category, code: code, msg: msg}, 1)
+}
+
+// Codedf biz error with category and stable code, formats according to a format specifier
+func Codedf(category Category, code string, format string, a ...interface{}) error {
+	return wrapStack(&CodedError{category: category, code: code, msg: fmt.Sprintf(format, a...)}, 1)
+}
+
+// NewNotFoundError biz not found error, e.g. NewNotFoundError(&User{}, 1) => "User 1 not found"
+func NewNotFoundError(model interface{}, keys ...interface{}) error {
+	name, ok := model.(string)
+	if !ok {
+		name = reflect.Indirect(reflect.ValueOf(model)).Type().Name()
+	}
+
+	msg := name + " not found"
+	if len(keys) != 0 {
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = fmt.Sprint(key)
+		}
+		msg = fmt.Sprintf("%s %s not found", name, strings.Join(parts, ","))
+	}
+
+	return wrapStack(&CodedError{category: CategoryNotFound, code: string(CategoryNotFound), msg: msg}, 1)
+}
+
+// CodedError 带分类和错误码的错误, 4xx 分类的错误同时也是业务错误 (CustomError)
+type CodedError struct {
+	category Category
+	code     string
+	msg      string
+}
+
+func (e *CodedError) Error() string {
+	return e.msg
+}
+
+// Code stable error code
+func (e *CodedError) Code() string {
+	if e.code == "" {
+		return string(e.Category())
+	}
+	return e.code
+}
+
+// Category error category
+func (e *CodedError) Category() Category {
+	if e.category == "" {
+		return CategoryUnknown
+	}
+	return e.category
+}
+
+// CustomError 4xx 分类的错误作为业务错误
+func (e *CodedError) CustomError() bool {
+	return e.Category().IsClientError()
+}
+
+// MessageKey code as the key of the localized message, see Localize
+func (e *CodedError) MessageKey() string {
+	return e.Code()
+}
+
+// MessageParams coded errors have no template params
+func (e *CodedError) MessageParams() Params {
+	return nil
+}
+
+// Is reports whether target is a CodedError with the same category and code,
+// so coded errors created on each call can still be used as sentinels.
+func (e *CodedError) Is(target error) bool {
+	var t *CodedError
+	if !As(target, &t) {
+		return false
+	}
+	return e.Category() == t.Category() && e.Code() == t.Code()
+}
+
+// CategoryOf return category of err:
+//   - category of the first error in the chain implementing Category()
+//   - CategoryCanceled / CategoryDeadlineExceeded for context errors
+//   - CategoryInvalidArgument for other CustomError
+//   - CategoryInternal for others
+func CategoryOf(err error) Category {
+	if err == nil {
+		return ""
+	}
+
+	type categorizer interface {
+		Category() Category
+	}
+
+	var category Category
+	walk(err, func(e error) bool {
+		if c, ok := e.(categorizer); ok {
+			category = c.Category()
+			return true
+		}
+		return false
+	})
+	if category != "" {
+		return category
+	}
+
+	root := Cause(err)
+	switch {
+	case root == context.Canceled:
+		return CategoryCanceled
+	case root == context.DeadlineExceeded:
+		return CategoryDeadlineExceeded
+	}
+	if _, ok := IsCustomError(err); ok {
+		return CategoryInvalidArgument
+	}
+	return CategoryInternal
+}
+
+// CodeOf return code of the first coded error in the chain, or "" if not found
+func CodeOf(err error) string {
+	type coder interface {
+		Code() string
+	}
+
+	var code string
+	walk(err, func(e error) bool {
+		if c, ok := e.(coder); ok {
+			code = c.Code()
+			return true
+		}
+		return false
+	})
+	return code
+}
+
+// HTTPStatus return HTTP status code for err, 200 for nil
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	return CategoryOf(err).HTTPStatus()
+}
+
+// GRPCCode return gRPC code for err, 0 (OK) for nil
+func GRPCCode(err error) uint32 {
+	if err == nil {
+		return 0
+	}
+	return CategoryOf(err).GRPCCode()
+}
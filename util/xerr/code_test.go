@@ -0,0 +1,60 @@
+package xerr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dummy struct{}
+
+func TestCodedError(t *testing.T) {
+	err := Coded(CategoryConflict, "ORDER_PAID", "order already paid")
+	assert.Equal(t, "order already paid", err.Error())
+	assert.Equal(t, CategoryConflict, CategoryOf(err))
+	assert.Equal(t, "ORDER_PAID", CodeOf(err))
+	assert.Equal(t, http.StatusConflict, HTTPStatus(err))
+	assert.Equal(t, uint32(10), GRPCCode(err))
+	_, ok := IsCustomError(err)
+	assert.True(t, ok)
+
+	err = Wrap(Codedf(CategoryUnavailable, "", "redis %s down", "cache"), "get user")
+	assert.Equal(t, CategoryUnavailable, CategoryOf(err))
+	assert.Equal(t, "UNAVAILABLE", CodeOf(err))
+	assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(err))
+	_, ok = IsCustomError(err)
+	assert.False(t, ok, "5xx coded error is not a custom error")
+
+	err = NewNotFoundError(&dummy{}, "test1")
+	assert.Equal(t, "dummy test1 not found", err.Error())
+	assert.Equal(t, CategoryNotFound, CategoryOf(err))
+	assert.Equal(t, "user not found", NewNotFoundError("user").Error())
+}
+
+func TestCategoryOf(t *testing.T) {
+	tcs := []struct {
+		err      error
+		category Category
+		status   int
+		code     uint32
+	}{
+		{nil, "", http.StatusOK, 0},
+		{io.EOF, CategoryInternal, http.StatusInternalServerError, 13},
+		{Wrap(context.Canceled), CategoryCanceled, StatusClientClosedRequest, 1},
+		{WithStack(context.DeadlineExceeded), CategoryDeadlineExceeded, http.StatusGatewayTimeout, 4},
+		{Custom("invalid name"), CategoryInvalidArgument, http.StatusBadRequest, 3},
+		{Coded(CategoryUnauthenticated, "", ""), CategoryUnauthenticated, http.StatusUnauthorized, 16},
+		{Coded(CategoryPermissionDenied, "", ""), CategoryPermissionDenied, http.StatusForbidden, 7},
+		{Coded("", "", ""), CategoryUnknown, http.StatusInternalServerError, 2},
+		{errors.New("unknown"), CategoryInternal, http.StatusInternalServerError, 13},
+	}
+	for _, tc := range tcs {
+		assert.Equal(t, tc.category, CategoryOf(tc.err), "%v", tc.err)
+		assert.Equal(t, tc.status, HTTPStatus(tc.err), "%v", tc.err)
+		assert.Equal(t, tc.code, GRPCCode(tc.err), "%v", tc.err)
+	}
+}
@@ -19,14 +19,41 @@ func Customf(format string, a ...interface{}) error {
 	return wrapStack(&CustomError{msg: fmt.Sprintf(format, a...)}, 1)
 }
 
+// Localized biz custom error with message key and template params,
+// the message is rendered by the message catalog for user's language, see Localize.
+//
+//	xerr.Localized("ORDER_NOT_FOUND", xerr.Params{"id": id})
+func Localized(key string, params Params) error {
+	return wrapStack(&CustomError{key: key, params: params}, 1)
+}
+
 // CustomError 业务错误代码，不应该返回 500 错误
 // 同时，这个错误不会上传到 NewRelic
 type CustomError struct {
-	msg string
+	msg    string
+	key    string
+	params Params
 }
 
+// Error message in the default language for localized error
 func (e *CustomError) Error() string {
-	return e.msg
+	if e.key == "" {
+		return e.msg
+	}
+	if msg, ok := MessageCatalog().Render(e.key, e.params); ok {
+		return msg
+	}
+	return e.key
+}
+
+// MessageKey key of the localized message, empty if not localized
+func (e *CustomError) MessageKey() string {
+	return e.key
+}
+
+// MessageParams template params of the localized message
+func (e *CustomError) MessageParams() Params {
+	return e.params
 }
 
 func (e *CustomError) CustomError() bool {
@@ -39,12 +66,12 @@ func IsCustomError(err error) (error, bool) {
 		CustomError() bool
 	}
 
-	_, ok := Cause(err).(custom)
-	return err, ok
+	c, ok := Cause(err).(custom)
+	return err, ok && c.CustomError()
 }
 
 // IgnoreDuplicateEntryError ignore duplicate entry error,
-// return nil if error message contain Duplicate entry,
+// return nil if err is a unique violation (see IsUniqueError),
 // return err in other errors.
 func IgnoreDuplicateEntryError(err error, msg ...interface{}) error {
 	if IsUniqueError(err) {
@@ -59,10 +86,14 @@ func IgnoreDuplicateEntryError(err error, msg ...interface{}) error {
 	return wrapStack(err, 1)
 }
 
-// IsUniqueError 判断是否是 sql unique 错误
+// IsUniqueError 判断是否是 sql unique 错误, 按驱动错误码识别 (MySQL, PostgreSQL, SQLite),
+// 驱动错误类型丢失时 (如被转成字符串) 才退回匹配 MySQL 的 "Duplicate entry"
 func IsUniqueError(err error) bool {
 	if err == nil {
 		return false
 	}
+	if kind := ClassifyDBError(err); kind != DBErrUnknown {
+		return kind == DBErrUniqueViolation
+	}
 	return strings.Contains(err.Error(), "Duplicate entry")
 }
@@ -0,0 +1,176 @@
+package xerr
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// DBErrorKind 数据库错误分类, 按驱动的错误码识别, 不依赖错误信息的语言和格式
+type DBErrorKind string
+
+const (
+	// DBErrUnknown not a database error, or not classified
+	DBErrUnknown DBErrorKind = ""
+	// DBErrUniqueViolation unique / primary key constraint violation
+	DBErrUniqueViolation DBErrorKind = "UNIQUE_VIOLATION"
+	// DBErrForeignKeyViolation foreign key constraint violation
+	DBErrForeignKeyViolation DBErrorKind = "FOREIGN_KEY_VIOLATION"
+	// DBErrDeadlock deadlock or serialization failure, the transaction was rolled back
+	DBErrDeadlock DBErrorKind = "DEADLOCK"
+	// DBErrLockTimeout lock wait timeout, or the database is locked (SQLite busy)
+	DBErrLockTimeout DBErrorKind = "LOCK_TIMEOUT"
+	// DBErrConnection the connection is broken or refused
+	DBErrConnection DBErrorKind = "CONNECTION"
+)
+
+// ClassifyDBError classifies the first database error in err's tree.
+// Supported error shapes:
+//   - MySQL: github.com/go-sql-driver/mysql MySQLError (Number), ErrInvalidConn
+//   - PostgreSQL: github.com/lib/pq Error, github.com/jackc/pgconn PgError (SQLState)
+//   - SQLite: github.com/mattn/go-sqlite3 Error (ExtendedCode), modernc.org/sqlite Error (Code())
+//   - database/sql/driver.ErrBadConn
+//
+// Drivers are matched by shape and package path through reflection, so none of them is imported,
+// vendored copies of the drivers are matched too.
+// The first classified error wins, wrappers of the driver error are transparent.
+func ClassifyDBError(err error) DBErrorKind {
+	kind := DBErrUnknown
+	walk(err, func(e error) bool {
+		kind = dbErrorKind(e)
+		return kind != DBErrUnknown
+	})
+	return kind
+}
+
+// IsForeignKeyError 判断是否是外键约束错误
+func IsForeignKeyError(err error) bool {
+	return ClassifyDBError(err) == DBErrForeignKeyViolation
+}
+
+// IsDeadlockError 判断是否是死锁 (或序列化失败) 错误, 可以重试整个事务
+func IsDeadlockError(err error) bool {
+	return ClassifyDBError(err) == DBErrDeadlock
+}
+
+// IsLockTimeoutError 判断是否是锁等待超时错误
+func IsLockTimeoutError(err error) bool {
+	return ClassifyDBError(err) == DBErrLockTimeout
+}
+
+// IsConnectionError 判断是否是数据库连接错误
+func IsConnectionError(err error) bool {
+	return ClassifyDBError(err) == DBErrConnection
+}
+
+// mysqlInvalidConn same as github.com/go-sql-driver/mysql.ErrInvalidConn,
+// the driver returns it when the connection is lost in the middle of a query
+var mysqlInvalidConn = errors.New("invalid connection")
+
+func dbErrorKind(err error) DBErrorKind {
+	if err == driver.ErrBadConn || isMySQLInvalidConn(err) {
+		return DBErrConnection
+	}
+
+	// lib/pq, pgx
+	if s, ok := err.(interface{ SQLState() string }); ok {
+		return postgresErrorKind(s.SQLState())
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(err))
+	if v.Kind() != reflect.Struct {
+		return DBErrUnknown
+	}
+	pkg := v.Type().PkgPath()
+
+	// modernc.org/sqlite, Code() returns the extended result code
+	if c, ok := err.(interface{ Code() int }); ok && isPkg(pkg, "modernc.org/sqlite") {
+		return sqliteErrorKind(c.Code())
+	}
+	// go-sql-driver/mysql
+	if f := v.FieldByName("Number"); f.IsValid() && isUintKind(f.Kind()) && isPkg(pkg, "github.com/go-sql-driver/mysql") {
+		return mysqlErrorKind(f.Uint())
+	}
+	// mattn/go-sqlite3
+	if f := v.FieldByName("ExtendedCode"); f.IsValid() && isIntKind(f.Kind()) && isPkg(pkg, "github.com/mattn/go-sqlite3") {
+		return sqliteErrorKind(int(f.Int()))
+	}
+	// lib/pq before SQLState() was added
+	if f := v.FieldByName("Code"); f.IsValid() && f.Kind() == reflect.String && isPkg(pkg, "github.com/lib/pq") {
+		return postgresErrorKind(f.String())
+	}
+	return DBErrUnknown
+}
+
+// isMySQLInvalidConn reports whether err is the ErrInvalidConn sentinel of go-sql-driver/mysql,
+// it is created by errors.New, so the type and the message identify it
+func isMySQLInvalidConn(err error) bool {
+	return reflect.TypeOf(err) == reflect.TypeOf(mysqlInvalidConn) && err.Error() == mysqlInvalidConn.Error()
+}
+
+// isPkg reports whether pkg is path, or a vendored copy of it
+func isPkg(pkg, path string) bool {
+	return pkg == path || strings.HasSuffix(pkg, "/"+path)
+}
+
+// https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+func mysqlErrorKind(number uint64) DBErrorKind {
+	switch number {
+	case 1062, 1586: // ER_DUP_ENTRY, ER_DUP_ENTRY_WITH_KEY_NAME
+		return DBErrUniqueViolation
+	case 1216, 1217, 1451, 1452: // ER_NO_REFERENCED_ROW(_2), ER_ROW_IS_REFERENCED(_2)
+		return DBErrForeignKeyViolation
+	case 1213: // ER_LOCK_DEADLOCK
+		return DBErrDeadlock
+	case 1205: // ER_LOCK_WAIT_TIMEOUT
+		return DBErrLockTimeout
+	case 1040, 1053: // ER_CON_COUNT_ERROR, ER_SERVER_SHUTDOWN
+		return DBErrConnection
+	}
+	return DBErrUnknown
+}
+
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+func postgresErrorKind(state string) DBErrorKind {
+	switch state {
+	case "23505": // unique_violation
+		return DBErrUniqueViolation
+	case "23503": // foreign_key_violation
+		return DBErrForeignKeyViolation
+	case "40001", "40P01": // serialization_failure, deadlock_detected
+		return DBErrDeadlock
+	case "55P03": // lock_not_available
+		return DBErrLockTimeout
+	case "57P01", "57P02", "57P03": // admin_shutdown, crash_shutdown, cannot_connect_now
+		return DBErrConnection
+	}
+	if strings.HasPrefix(state, "08") { // connection_exception
+		return DBErrConnection
+	}
+	return DBErrUnknown
+}
+
+// https://www.sqlite.org/rescode.html
+func sqliteErrorKind(code int) DBErrorKind {
+	switch code {
+	case 1555, 2067: // SQLITE_CONSTRAINT_PRIMARYKEY, SQLITE_CONSTRAINT_UNIQUE
+		return DBErrUniqueViolation
+	case 787: // SQLITE_CONSTRAINT_FOREIGNKEY
+		return DBErrForeignKeyViolation
+	}
+	// primary result code is the low 8 bits of the extended code
+	switch code & 0xff {
+	case 5, 6: // SQLITE_BUSY, SQLITE_LOCKED
+		return DBErrLockTimeout
+	}
+	return DBErrUnknown
+}
+
+func isUintKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uint64
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
@@ -0,0 +1,77 @@
+package xerr
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaolion/gostack/util/xerr/internal/fakedriver/github.com/go-sql-driver/mysql"
+	sqlite3 "github.com/zhaolion/gostack/util/xerr/internal/fakedriver/github.com/mattn/go-sqlite3"
+)
+
+type (
+	mysqlError   = mysql.MySQLError
+	sqlite3Error = sqlite3.Error
+)
+
+// other struct with a Number field
+type numberError struct {
+	Number uint16
+}
+
+func (e numberError) Error() string { return "number error" }
+
+func TestClassifyDBError(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want DBErrorKind
+	}{
+		{nil, DBErrUnknown},
+		{io.EOF, DBErrUnknown},
+		{New("Duplicate entry"), DBErrUnknown},
+		{&mysqlError{Number: 1062, Message: "Duplicate entry 'a' for key 'uk_name'"}, DBErrUniqueViolation},
+		{&mysqlError{Number: 1452}, DBErrForeignKeyViolation},
+		{&mysqlError{Number: 1213}, DBErrDeadlock},
+		{&mysqlError{Number: 1205}, DBErrLockTimeout},
+		{&mysqlError{Number: 1040}, DBErrConnection},
+		{mysql.ErrInvalidConn, DBErrConnection},
+		{fmt.Errorf("query: %w", mysql.ErrInvalidConn), DBErrConnection},
+		{New("invalid connection"), DBErrUnknown},
+		{&mysqlError{Number: 1064}, DBErrUnknown},
+		{numberError{Number: 1062}, DBErrUnknown},
+		{sqlStateError("23505"), DBErrUniqueViolation},
+		{sqlStateError("23503"), DBErrForeignKeyViolation},
+		{sqlStateError("40P01"), DBErrDeadlock},
+		{sqlStateError("55P03"), DBErrLockTimeout},
+		{sqlStateError("08006"), DBErrConnection},
+		{sqlStateError("42601"), DBErrUnknown},
+		{sqlite3Error{Code: 19, ExtendedCode: 2067}, DBErrUniqueViolation},
+		{sqlite3Error{Code: 19, ExtendedCode: 787}, DBErrForeignKeyViolation},
+		{sqlite3Error{Code: 5, ExtendedCode: 517}, DBErrLockTimeout},
+		{driver.ErrBadConn, DBErrConnection},
+		{Wrap(fmt.Errorf("insert: %w", &mysqlError{Number: 1062}), "save user"), DBErrUniqueViolation},
+	} {
+		assert.Equal(t, tt.want, ClassifyDBError(tt.err), "%v", tt.err)
+	}
+}
+
+func TestIsUniqueError(t *testing.T) {
+	assert.True(t, IsUniqueError(Wrap(sqlStateError("23505"))))
+	assert.True(t, IsUniqueError(sqlite3Error{ExtendedCode: 1555}))
+	// 驱动错误类型丢失, 退回匹配错误信息
+	assert.True(t, IsUniqueError(New("Error 1062: Duplicate entry 'a' for key 'uk_name'")))
+	// 有错误码时不看错误信息
+	assert.False(t, IsUniqueError(&mysqlError{Number: 1452, Message: "Duplicate entry"}))
+
+	assert.True(t, IsForeignKeyError(&mysqlError{Number: 1451}))
+	assert.True(t, IsDeadlockError(sqlStateError("40001")))
+	assert.True(t, IsLockTimeoutError(&mysqlError{Number: 1205}))
+	assert.True(t, IsConnectionError(Wrap(driver.ErrBadConn)))
+	assert.True(t, IsConnectionError(Wrap(mysql.ErrInvalidConn)))
+
+	assert.True(t, IsRetryable(&mysqlError{Number: 1213}))
+	assert.False(t, IsRetryable(&mysqlError{Number: 1062}))
+	assert.NoError(t, IgnoreDuplicateEntryError(&mysqlError{Number: 1062}, "a"))
+}
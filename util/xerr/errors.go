@@ -163,7 +163,10 @@ func (w *withStack) Format(s fmt.State, verb rune) {
 	case 'v':
 		if s.Flag('+') {
 			fmt.Fprintf(s, "%+v", w.Cause())
-			w.stack.Format(s, verb)
+			// 复用内层堆栈时不重复输出
+			if stackOf(w.Cause()) != w.stack {
+				w.stack.Format(s, verb)
+			}
 			return
 		}
 		fallthrough
@@ -218,6 +221,7 @@ type withMessage struct {
 
 func (w *withMessage) Error() string { return w.msg + ": " + w.cause.Error() }
 func (w *withMessage) Cause() error  { return w.cause }
+func (w *withMessage) Unwrap() error { return w.cause }
 
 func (w *withMessage) Format(s fmt.State, verb rune) {
 	switch verb {
@@ -246,7 +250,9 @@ func wrapStack(err error, skip int) error {
 	if _, ok := err.(stackTracer); ok {
 		return err
 	}
-	if _, ok := err.(interface{ Cause() error }); ok {
+	// 内层已有堆栈时复用, 不重复采集
+	if st := stackOf(err); st != nil {
+		return &withStack{err, st}
 	}
 	return &withStack{
 		err,
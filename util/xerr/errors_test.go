@@ -2,6 +2,8 @@ package xerr
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,3 +19,43 @@ func Test_withStack_Unwrap(t *testing.T) {
 	assert.True(t, errors.Is(stackErr, err))
 	assert.ErrorIs(t, stackErr, err)
 }
+
+type multiError []error
+
+func (m multiError) Error() string   { return "multi" }
+func (m multiError) Unwrap() []error { return m }
+
+var errSentinel = New("sentinel")
+
+func TestIsAs(t *testing.T) {
+	err := WithMessage(Wrap(errSentinel, "load"), "handle")
+	assert.True(t, errors.Is(err, errSentinel))
+	assert.True(t, Is(err, errSentinel))
+	assert.False(t, Is(err, New("sentinel")), "same message is not the same error")
+	assert.True(t, ErrorEqual(err, io.EOF, errSentinel))
+	assert.False(t, ErrorEqual(err, New("sentinel")))
+	assert.False(t, ErrorEqual(nil, errSentinel))
+
+	// mix with fmt.Errorf("%w")
+	err = fmt.Errorf("outer: %w", Wrap(Custom("biz"), "inner"))
+	var custom *CustomError
+	assert.True(t, errors.As(err, &custom))
+	assert.True(t, As(err, &custom))
+	assert.Equal(t, "biz", custom.Error())
+
+	// multi unwrap
+	err = Wrap(multiError{io.EOF, WithMessage(Coded(CategoryNotFound, "USER", "user"), "find")})
+	assert.True(t, Is(err, io.EOF))
+	assert.True(t, Is(err, Coded(CategoryNotFound, "USER", "another message")))
+	assert.False(t, Is(err, Coded(CategoryNotFound, "ORDER", "user")))
+	var coded *CodedError
+	assert.True(t, As(err, &coded))
+	assert.Equal(t, "USER", coded.Code())
+	assert.Equal(t, CategoryNotFound, CategoryOf(err))
+
+	var iface interface{ Code() string }
+	assert.True(t, As(err, &iface))
+	assert.False(t, As(io.EOF, &coded))
+	assert.Panics(t, func() { As(err, nil) })
+	assert.Panics(t, func() { As(err, coded) })
+}
@@ -0,0 +1,78 @@
+package xerr
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/zhaolion/gostack/util/log"
+)
+
+// WithField annotates err with a structured field, e.g. user_id.
+// If err is nil, WithField returns nil.
+func WithField(err error, key string, value interface{}) error {
+	return WithFields(err, log.Fields{key: value})
+}
+
+// WithFields annotates err with structured fields.
+// Fields accumulate through the wrap chain and are emitted as log fields
+// by ReportError / WrapWithLog, instead of being interpolated into messages.
+// If err is nil, WithFields returns nil.
+func WithFields(err error, fields log.Fields) error {
+	if err == nil {
+		return nil
+	}
+	if len(fields) == 0 {
+		return err
+	}
+
+	return &withFields{
+		cause:  err,
+		fields: fields,
+	}
+}
+
+// FieldsOf collects fields of the whole chain,
+// fields annotated later (outer) override the earlier (inner) ones.
+func FieldsOf(err error) log.Fields {
+	type fielder interface {
+		Fields() log.Fields
+	}
+
+	fields := log.Fields{}
+	walk(err, func(e error) bool {
+		if f, ok := e.(fielder); ok {
+			for k, v := range f.Fields() {
+				if _, exist := fields[k]; !exist {
+					fields[k] = v
+				}
+			}
+		}
+		return false
+	})
+	return fields
+}
+
+type withFields struct {
+	cause  error
+	fields log.Fields
+}
+
+func (w *withFields) Error() string      { return w.cause.Error() }
+func (w *withFields) Cause() error       { return w.cause }
+func (w *withFields) Unwrap() error      { return w.cause }
+func (w *withFields) Fields() log.Fields { return w.fields }
+
+func (w *withFields) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", w.cause)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
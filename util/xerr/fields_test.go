@@ -0,0 +1,48 @@
+package xerr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhaolion/gostack/util/log"
+)
+
+func TestFieldsOf(t *testing.T) {
+	assert.Nil(t, WithField(nil, "user_id", 1))
+	assert.Equal(t, io.EOF, WithFields(io.EOF, nil))
+
+	err := WithField(io.EOF, "user_id", 1)
+	err = Wrap(err, "load order")
+	err = WithFields(err, log.Fields{"order_id": "o-1", "user_id": 2, "retryable": true})
+	assert.Equal(t, "load order: EOF", err.Error())
+	assert.Equal(t, io.EOF, Cause(err))
+	assert.True(t, Is(err, io.EOF))
+	assert.Equal(t, log.Fields{"user_id": 2, "order_id": "o-1", "retryable": true}, FieldsOf(err))
+	assert.Equal(t, log.Fields{}, FieldsOf(io.EOF))
+}
+
+func TestReportErrorFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stdout)
+
+	err := WithField(Wrap(io.EOF), "user_id", 1)
+	require.Error(t, ReportError(context.Background(), err))
+
+	entry := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, float64(1), entry["user_id"])
+	assert.Equal(t, "error", entry["level"])
+
+	buf.Reset()
+	require.Error(t, WrapWithLog(WithField(io.EOF, "order_id", "o-1")))
+	entry = map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "o-1", entry["order_id"])
+}
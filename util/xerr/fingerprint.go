@@ -0,0 +1,174 @@
+package xerr
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zhaolion/gostack/util/log"
+)
+
+// FingerprintDepth number of top stack frames used by Fingerprint
+var FingerprintDepth = 5
+
+// Fingerprint identifies errors of the same type raised at the same place:
+// type of the root cause plus function and line of the top frames of
+// the innermost stack trace. Messages are not used, so errors carrying
+// ids or timestamps in their messages still share the fingerprint.
+// Errors without any stack trace fall back to the root cause message.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var st StackTrace
+	walk(err, func(e error) bool {
+		if s, ok := e.(stackTracer); ok {
+			st = s.StackTrace()
+		}
+		return false
+	})
+
+	root := Cause(err)
+	h := sha1.New()
+	_, _ = h.Write([]byte(reflect.TypeOf(root).String()))
+	if len(st) == 0 {
+		_, _ = h.Write([]byte(root.Error()))
+	}
+	if len(st) > FingerprintDepth {
+		st = st[:FingerprintDepth]
+	}
+	for _, f := range st {
+		_, _ = fmt.Fprintf(h, "\n%s:%d", f.name(), f.line())
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+var summary struct {
+	mu         sync.Mutex
+	summarizer *summarizer
+}
+
+// SetReportSummary enables the rate-limited mode of ReportError:
+// the first occurrence of a fingerprint is logged and reported in full,
+// the following ones are only counted and logged as a summary every interval,
+// e.g. "EOF: seen 1,532 times in last 1m0s". A fingerprint quiet for a whole
+// interval is forgotten, its next occurrence is logged in full again.
+// interval <= 0 disables the mode and logs pending summaries.
+func SetReportSummary(interval time.Duration) {
+	summary.mu.Lock()
+	defer summary.mu.Unlock()
+
+	if summary.summarizer != nil {
+		summary.summarizer.stop()
+		summary.summarizer = nil
+	}
+	if interval > 0 {
+		summary.summarizer = newSummarizer(interval)
+	}
+}
+
+// observeReport returns false if the report of err should be suppressed
+func observeReport(err error) bool {
+	summary.mu.Lock()
+	s := summary.summarizer
+	summary.mu.Unlock()
+
+	if s == nil {
+		return true
+	}
+	return s.observe(Fingerprint(err), err, time.Now())
+}
+
+type summaryStat struct {
+	msg   string
+	count int
+	since time.Time
+}
+
+type summarizer struct {
+	interval time.Duration
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	mu    sync.Mutex
+	stats map[string]*summaryStat
+}
+
+func newSummarizer(interval time.Duration) *summarizer {
+	s := &summarizer{
+		interval: interval,
+		done:     make(chan struct{}),
+		stats:    make(map[string]*summaryStat),
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				s.flush(now)
+			case <-s.done:
+				s.flush(time.Now())
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// observe counts the occurrence, returns true for the first one
+func (s *summarizer) observe(fp string, err error, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stat, ok := s.stats[fp]; ok {
+		stat.count++
+		return false
+	}
+	s.stats[fp] = &summaryStat{msg: err.Error(), since: now}
+	return true
+}
+
+// flush logs summaries of repeated errors and forgets quiet fingerprints
+func (s *summarizer) flush(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for fp, stat := range s.stats {
+		if stat.count == 0 {
+			if now.Sub(stat.since) >= s.interval {
+				delete(s.stats, fp)
+			}
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"fingerprint": fp,
+			"count":       stat.count,
+		}).Errorf("%s: seen %s times in last %s", stat.msg, formatCount(stat.count), now.Sub(stat.since).Round(time.Second))
+		stat.count = 0
+		stat.since = now
+	}
+}
+
+func (s *summarizer) stop() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+// formatCount formats n with thousands separators, e.g. 1,532
+func formatCount(n int) string {
+	str := strconv.Itoa(n)
+	for i := len(str) - 3; i > 0; i -= 3 {
+		str = str[:i] + "," + str[i:]
+	}
+	return str
+}
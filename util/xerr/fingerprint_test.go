@@ -0,0 +1,82 @@
+package xerr
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaolion/gostack/util/log"
+)
+
+func queryUser(id int) error {
+	return Errorf("query user %d: connection refused", id)
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, "", Fingerprint(nil))
+
+	var fps []string
+	for i := 0; i < 3; i++ {
+		fps = append(fps, Fingerprint(Wrap(queryUser(i), "load")))
+	}
+	assert.Equal(t, fps[0], fps[1], "messages are ignored")
+	assert.Equal(t, fps[0], fps[2])
+	assert.Len(t, fps[0], 16)
+
+	assert.NotEqual(t, fps[0], Fingerprint(Errorf("query user %d: connection refused", 1)), "different place")
+	assert.NotEqual(t, Fingerprint(io.EOF), Fingerprint(io.ErrUnexpectedEOF), "no stack, by message")
+	assert.Equal(t, Fingerprint(io.EOF), Fingerprint(WithMessage(io.EOF, "read")))
+}
+
+func TestFormatCount(t *testing.T) {
+	assert.Equal(t, "0", formatCount(0))
+	assert.Equal(t, "999", formatCount(999))
+	assert.Equal(t, "1,532", formatCount(1532))
+	assert.Equal(t, "1,234,567", formatCount(1234567))
+}
+
+func TestReportSummary(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stdout)
+
+	SetReportSummary(time.Hour)
+	defer SetReportSummary(0)
+
+	ctx := context.Background()
+	report := func() error {
+		return ReportError(ctx, queryUser(1))
+	}
+	for i := 0; i < 1533; i++ {
+		assert.Error(t, report())
+	}
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"), "only the first one is logged")
+
+	// disable logs pending summaries
+	SetReportSummary(0)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[1], "seen 1,532 times in last")
+		assert.Contains(t, lines[1], `"count":1532`)
+	}
+}
+
+func TestSummarizerFlush(t *testing.T) {
+	s := &summarizer{interval: time.Minute, stats: map[string]*summaryStat{}}
+	now := time.Now()
+
+	assert.True(t, s.observe("a", io.EOF, now))
+	assert.False(t, s.observe("a", io.EOF, now))
+	s.flush(now.Add(time.Minute))
+	assert.Equal(t, 0, s.stats["a"].count)
+
+	// quiet for a whole interval, forgotten
+	s.flush(now.Add(2 * time.Minute))
+	assert.Empty(t, s.stats)
+	assert.True(t, s.observe("a", io.EOF, now))
+}
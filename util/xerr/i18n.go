@@ -0,0 +1,212 @@
+package xerr
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Params template params of a localized message
+type Params map[string]interface{}
+
+// DefaultLang default language of the message catalog
+const DefaultLang = "en"
+
+// Catalog 多语言错误信息, language => message key => template.
+// Templates refer params by name, e.g. "order {id} not found".
+type Catalog struct {
+	mu          sync.RWMutex
+	defaultLang string
+	messages    map[string]map[string]string
+}
+
+// NewCatalog return an empty catalog, defaultLang is used when user's languages are not available
+func NewCatalog(defaultLang string) *Catalog {
+	return &Catalog{
+		defaultLang: normalizeLang(defaultLang),
+		messages:    make(map[string]map[string]string),
+	}
+}
+
+// DefaultLang default language of the catalog
+func (c *Catalog) DefaultLang() string {
+	return c.defaultLang
+}
+
+// Add adds messages of lang, existing keys are overridden
+func (c *Catalog) Add(lang string, messages map[string]string) {
+	lang = normalizeLang(lang)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.messages[lang] == nil {
+		c.messages[lang] = make(map[string]string, len(messages))
+	}
+	for key, tmpl := range messages {
+		c.messages[lang][key] = tmpl
+	}
+}
+
+// Render renders message of key in the first available language of langs,
+// "zh-CN" falls back to "zh", then the default language is used.
+func (c *Catalog) Render(key string, params Params, langs ...string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	candidates := make([]string, 0, len(langs)+1)
+	candidates = append(candidates, langs...)
+	for _, lang := range append(candidates, c.defaultLang) {
+		lang = normalizeLang(lang)
+		for lang != "" {
+			if tmpl, ok := c.messages[lang][key]; ok {
+				return renderMessage(tmpl, params), true
+			}
+			i := strings.LastIndexByte(lang, '-')
+			if i < 0 {
+				break
+			}
+			lang = lang[:i]
+		}
+	}
+	return "", false
+}
+
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+func renderMessage(tmpl string, params Params) string {
+	if len(params) == 0 {
+		return tmpl
+	}
+	return placeholder.ReplaceAllStringFunc(tmpl, func(s string) string {
+		if v, ok := params[s[1:len(s)-1]]; ok {
+			return fmt.Sprint(v)
+		}
+		return s
+	})
+}
+
+func normalizeLang(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
+
+var catalog atomic.Value
+
+func init() {
+	catalog.Store(NewCatalog(DefaultLang))
+}
+
+// MessageCatalog return the catalog used by Localize, load messages by config.LoadMessages
+func MessageCatalog() *Catalog {
+	return catalog.Load().(*Catalog)
+}
+
+// SetMessageCatalog replaces the catalog used by Localize
+func SetMessageCatalog(c *Catalog) {
+	catalog.Store(c)
+}
+
+// Localize renders the user-facing message of err in the first available language of langs.
+// The first error in the chain having a message key is rendered, i.e. errors created by
+// Localized, or coded errors (code as the key). Without catalog entries the message of the
+// first custom or coded error in the chain is returned, messages of wrapping errors are not
+// included. err.Error() is returned only if there is no such error.
+func Localize(err error, langs ...string) string {
+	if err == nil {
+		return ""
+	}
+
+	type localizer interface {
+		MessageKey() string
+		MessageParams() Params
+	}
+
+	msg, fallback := "", ""
+	walk(err, func(e error) bool {
+		l, ok := e.(localizer)
+		if !ok {
+			return false
+		}
+		if fallback == "" {
+			fallback = fallbackMessage(e, l.MessageKey(), l.MessageParams())
+		}
+		if l.MessageKey() == "" {
+			return false
+		}
+		msg, ok = MessageCatalog().Render(l.MessageKey(), l.MessageParams(), langs...)
+		return ok
+	})
+	if msg != "" {
+		return msg
+	}
+	if fallback != "" {
+		return fallback
+	}
+	return err.Error()
+}
+
+// fallbackMessage message of a localizable error without catalog entry,
+// params are appended to the key of localized errors, e.g. "ORDER_NOT_FOUND (id=42)"
+func fallbackMessage(err error, key string, params Params) string {
+	msg := err.Error()
+	if msg != key || len(params) == 0 {
+		return msg
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%v", name, params[name])
+	}
+	return msg + " (" + strings.Join(parts, ", ") + ")"
+}
+
+// LocalizeRequest renders err in languages of the request's Accept-Language header
+func LocalizeRequest(err error, r *http.Request) string {
+	return Localize(err, ParseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+}
+
+// ParseAcceptLanguage return languages of Accept-Language header ordered by quality,
+// e.g. "zh-CN,zh;q=0.9,en;q=0.8" => [zh-cn zh en]
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var items []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		lang := normalizeLang(fields[0])
+		if lang == "" || lang == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, f := range fields[1:] {
+			if v := strings.TrimSpace(f); strings.HasPrefix(v, "q=") {
+				if parsed, err := strconv.ParseFloat(v[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			items = append(items, weighted{lang, q})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].q > items[j].q })
+	langs := make([]string, len(items))
+	for i, item := range items {
+		langs[i] = item.lang
+	}
+	return langs
+}
@@ -0,0 +1,54 @@
+package xerr
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalize(t *testing.T) {
+	defer SetMessageCatalog(MessageCatalog())
+
+	catalog := NewCatalog("en")
+	catalog.Add("en", map[string]string{
+		"ORDER_NOT_FOUND":  "order {id} not found",
+		"PAYMENT_DECLINED": "payment declined",
+	})
+	catalog.Add("zh", map[string]string{
+		"ORDER_NOT_FOUND": "订单 {id} 不存在",
+	})
+	SetMessageCatalog(catalog)
+
+	err := Wrap(Localized("ORDER_NOT_FOUND", Params{"id": 42}), "load order")
+	assert.Equal(t, "load order: order 42 not found", err.Error())
+	assert.Equal(t, "订单 42 不存在", Localize(err, "zh-CN"))
+	assert.Equal(t, "order 42 not found", Localize(err, "ja", "fr"))
+	_, ok := IsCustomError(err)
+	assert.True(t, ok)
+
+	// coded error use code as the key
+	err = Coded(CategoryFailedPrecondition, "PAYMENT_DECLINED", "card declined by bank")
+	assert.Equal(t, "payment declined", Localize(err, "zh"))
+
+	assert.Equal(t, "MISSING_KEY", Localized("MISSING_KEY", nil).Error())
+	assert.Equal(t, "stock not enough", Localize(Custom("stock not enough"), "zh"))
+	assert.Equal(t, "", Localize(nil, "zh"))
+
+	// 没有翻译时只返回业务错误本身的信息
+	assert.Equal(t, "stock not enough", Localize(Wrap(WithMessage(Custom("stock not enough")), "insert order"), "zh"))
+	assert.Equal(t, "MISSING_KEY (id=42, sku=A1)", Localize(Wrap(Localized("MISSING_KEY", Params{"sku": "A1", "id": 42}), "load order")))
+	assert.Equal(t, "card declined by bank", Localize(WithMessage(Coded(CategoryFailedPrecondition, "CARD_DECLINED", "card declined by bank"), "pay")))
+	assert.Equal(t, "read config: EOF", Localize(Wrap(io.EOF, "read config")))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "en;q=0.5, zh-CN,zh;q=0.9")
+	assert.Equal(t, "订单 1 不存在", LocalizeRequest(Localized("ORDER_NOT_FOUND", Params{"id": 1}), r))
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []string{"zh-cn", "zh", "en"}, ParseAcceptLanguage("zh-CN,zh;q=0.9,en;q=0.8"))
+	assert.Equal(t, []string{"fr", "en"}, ParseAcceptLanguage("en;q=0.1, fr, *;q=0.5, de;q=0"))
+	assert.Empty(t, ParseAcceptLanguage(""))
+}
@@ -0,0 +1,22 @@
+// Package mysql mimics errors of github.com/go-sql-driver/mysql for tests,
+// the import path ends with github.com/go-sql-driver/mysql as the real one does.
+package mysql
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidConn same as github.com/go-sql-driver/mysql.ErrInvalidConn
+var ErrInvalidConn = errors.New("invalid connection")
+
+// MySQLError same shape as github.com/go-sql-driver/mysql.MySQLError
+type MySQLError struct {
+	Number   uint16
+	SQLState [5]byte
+	Message  string
+}
+
+func (e *MySQLError) Error() string {
+	return fmt.Sprintf("Error %d: %s", e.Number, e.Message)
+}
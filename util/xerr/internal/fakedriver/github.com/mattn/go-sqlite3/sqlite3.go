@@ -0,0 +1,11 @@
+// Package sqlite3 mimics errors of github.com/mattn/go-sqlite3 for tests,
+// the import path ends with github.com/mattn/go-sqlite3 as the real one does.
+package sqlite3
+
+// Error same shape as github.com/mattn/go-sqlite3.Error
+type Error struct {
+	Code         int
+	ExtendedCode int
+}
+
+func (e Error) Error() string { return "sqlite3 error" }
@@ -0,0 +1,114 @@
+package xerr
+
+import (
+	"encoding/json"
+
+	"github.com/zhaolion/gostack/util/log"
+)
+
+// JSONError is the JSON representation of an error,
+// keeps the stack trace in a single log field instead of multi-line %+v text.
+type JSONError struct {
+	Message  string       `json:"message"`
+	Code     string       `json:"code,omitempty"`
+	Category Category     `json:"category,omitempty"`
+	Causes   []string     `json:"causes,omitempty"`
+	Frames   []JSONFrame  `json:"frames,omitempty"`
+	Attrs    log.Fields   `json:"attrs,omitempty"`
+	Errors   []*JSONError `json:"errors,omitempty"`
+}
+
+// JSONFrame is the JSON representation of a stack Frame
+type JSONFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// ToJSON converts err to JSONError:
+//   - causes lists messages of the wrap chain from outer to the root cause
+//   - frames is the innermost (closest to the origin) stack trace of the chain
+//   - attrs are the fields added by WithField(s)
+//   - errors holds members of Multi
+func ToJSON(err error) *JSONError {
+	if err == nil {
+		return nil
+	}
+
+	j := &JSONError{
+		Message: err.Error(),
+		Code:    CodeOf(err),
+	}
+	if j.Code != "" {
+		j.Category = CategoryOf(err)
+	}
+	if fields := FieldsOf(err); len(fields) != 0 {
+		j.Attrs = fields
+	}
+
+	var st StackTrace
+	last := j.Message
+	for e := err; e != nil; e = unwrapOnce(e) {
+		if msg := e.Error(); msg != last {
+			j.Causes = append(j.Causes, msg)
+			last = msg
+		}
+		if s, ok := e.(stackTracer); ok {
+			st = s.StackTrace()
+		}
+		if m, ok := e.(*Multi); ok {
+			for _, member := range m.Errors() {
+				j.Errors = append(j.Errors, ToJSON(member))
+			}
+			break
+		}
+	}
+
+	for _, f := range st {
+		j.Frames = append(j.Frames, JSONFrame{
+			Func: f.name(),
+			File: f.file(),
+			Line: f.line(),
+		})
+	}
+	return j
+}
+
+// unwrapOnce returns the next error of a single chain
+func unwrapOnce(err error) error {
+	switch e := err.(type) {
+	case interface{ Cause() error }:
+		return e.Cause()
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	}
+	return nil
+}
+
+func (f *fundamental) MarshalJSON() ([]byte, error) { return json.Marshal(ToJSON(f)) }
+func (w *withStack) MarshalJSON() ([]byte, error)   { return json.Marshal(ToJSON(w)) }
+func (w *withMessage) MarshalJSON() ([]byte, error) { return json.Marshal(ToJSON(w)) }
+func (w *withFields) MarshalJSON() ([]byte, error)  { return json.Marshal(ToJSON(w)) }
+func (m *Multi) MarshalJSON() ([]byte, error)       { return json.Marshal(ToJSON(m)) }
+func (e *CustomError) MarshalJSON() ([]byte, error) { return json.Marshal(ToJSON(e)) }
+func (e *CodedError) MarshalJSON() ([]byte, error)  { return json.Marshal(ToJSON(e)) }
+
+// JSONHook replaces the error field (log.ErrorKey, set by WithError) with JSONError,
+// so JSONFormatter outputs it as an object instead of an escaped string.
+//
+//	log.AddHook(xerr.JSONHook{})
+//	log.WithError(err).Error("load order failed")
+type JSONHook struct{}
+
+// Levels fires on all levels
+func (JSONHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire converts the error field
+func (JSONHook) Fire(entry *log.Entry) error {
+	if err, ok := entry.Data[log.ErrorKey].(error); ok {
+		entry.Data[log.ErrorKey] = ToJSON(err)
+	}
+	return nil
+}
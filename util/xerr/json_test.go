@@ -0,0 +1,58 @@
+package xerr
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhaolion/gostack/util/log"
+)
+
+func TestToJSON(t *testing.T) {
+	assert.Nil(t, ToJSON(nil))
+
+	err := WithField(Wrap(Coded(CategoryNotFound, "USER_NOT_FOUND", "user not found"), "load user"), "user_id", 1)
+	j := ToJSON(err)
+	assert.Equal(t, "load user: user not found", j.Message)
+	assert.Equal(t, "USER_NOT_FOUND", j.Code)
+	assert.Equal(t, CategoryNotFound, j.Category)
+	assert.Equal(t, []string{"user not found"}, j.Causes)
+	assert.Equal(t, log.Fields{"user_id": 1}, j.Attrs)
+	require.NotEmpty(t, j.Frames)
+	assert.Equal(t, "github.com/zhaolion/gostack/util/xerr.TestToJSON", j.Frames[0].Func)
+	assert.Contains(t, j.Frames[0].File, "json_test.go")
+	assert.NotZero(t, j.Frames[0].Line)
+
+	data, err2 := json.Marshal(err)
+	require.NoError(t, err2)
+	got := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, "load user: user not found", got["message"])
+	assert.Equal(t, map[string]interface{}{"user_id": float64(1)}, got["attrs"])
+
+	j = ToJSON(Append(io.EOF, New("second")))
+	require.Len(t, j.Errors, 2)
+	assert.Equal(t, "EOF", j.Errors[0].Message)
+	assert.Equal(t, "second", j.Errors[1].Message)
+}
+
+func TestJSONHook(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := log.New()
+	logger.Out = buf
+	logger.Formatter = &log.JSONFormatter{}
+	logger.AddHook(JSONHook{})
+
+	logger.WithError(Wrap(io.EOF, "read config")).Error("load failed")
+
+	entry := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	errField, ok := entry["error"].(map[string]interface{})
+	require.True(t, ok, buf.String())
+	assert.Equal(t, "read config: EOF", errField["message"])
+	assert.Equal(t, []interface{}{"EOF"}, errField["causes"])
+	assert.NotEmpty(t, errField["frames"])
+}
@@ -0,0 +1,147 @@
+package xerr
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Multi aggregates several errors, e.g. config validation or health checks.
+//
+//	var errs error
+//	for _, item := range items {
+//		errs = xerr.Append(errs, validate(item))
+//	}
+//	return errs
+//
+// Every member keeps its own stack trace, Is/As match any of the members.
+type Multi struct {
+	errs []error
+}
+
+// Append appends errs to err and returns the aggregated error.
+// nil errors are skipped, members of Multi are flattened,
+// errors without stack record the stack at the point Append is called.
+// Append returns nil if there is no error at all.
+func Append(err error, errs ...error) error {
+	m := &Multi{}
+	m.append(err, 1)
+	for _, e := range errs {
+		m.append(e, 1)
+	}
+
+	if len(m.errs) == 0 {
+		return nil
+	}
+	return m
+}
+
+func (m *Multi) append(err error, skip int) {
+	if err == nil {
+		return
+	}
+	if other, ok := err.(*Multi); ok {
+		m.errs = append(m.errs, other.errs...)
+		return
+	}
+	m.errs = append(m.errs, wrapStack(err, skip+1))
+}
+
+// Errors returns members of the aggregated error
+func (m *Multi) Errors() []error {
+	return m.errs
+}
+
+// Len returns number of members
+func (m *Multi) Len() int {
+	return len(m.errs)
+}
+
+func (m *Multi) Error() string {
+	if len(m.errs) == 1 {
+		return m.errs[0].Error()
+	}
+
+	msgs := make([]string, len(m.errs))
+	for i, err := range m.errs {
+		msgs[i] = strconv.Itoa(i+1) + ") " + err.Error()
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(m.errs), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns members of the aggregated error, Go 1.20 multi errors style,
+// so errors.Is/As (Go 1.20+) and xerr.Is/As check every member.
+func (m *Multi) Unwrap() []error {
+	return m.errs
+}
+
+// Format %+v prints every member with its stack trace as a numbered list
+func (m *Multi) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%d errors occurred:", len(m.errs))
+			for i, err := range m.errs {
+				fmt.Fprintf(s, "\n[%d] %+v", i+1, err)
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, m.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", m.Error())
+	}
+}
+
+// Filter keeps members of err which keep returns true,
+// err which is not Multi is treated as a Multi with single member.
+// Filter returns nil if nothing is kept.
+func Filter(err error, keep func(error) bool) error {
+	if err == nil {
+		return nil
+	}
+
+	m, ok := err.(*Multi)
+	if !ok {
+		if keep(err) {
+			return err
+		}
+		return nil
+	}
+
+	kept := &Multi{}
+	for _, e := range m.errs {
+		if keep(e) {
+			kept.errs = append(kept.errs, e)
+		}
+	}
+	if len(kept.errs) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// WithoutCustom drops business errors (CustomError), keeps the ones need to report.
+// A Multi wrapped by WithMessage/WithStack is filtered too, if some members are dropped
+// the filtered Multi is returned without the wrapping messages.
+func WithoutCustom(err error) error {
+	keep := func(e error) bool {
+		_, ok := IsCustomError(e)
+		return !ok
+	}
+
+	var m *Multi
+	if _, ok := err.(*Multi); ok || !As(err, &m) {
+		return Filter(err, keep)
+	}
+	kept := Filter(m, keep)
+	if kept == nil {
+		return nil
+	}
+	if kept.(*Multi).Len() == m.Len() {
+		return err
+	}
+	return kept
+}
@@ -0,0 +1,71 @@
+package xerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppend(t *testing.T) {
+	assert.Nil(t, Append(nil))
+	assert.Nil(t, Append(nil, nil, nil))
+
+	var errs error
+	errs = Append(errs, io.EOF)
+	errs = Append(errs, nil, Custom("invalid name"))
+	errs = Append(errs, Append(io.ErrUnexpectedEOF, errSentinel))
+
+	m, ok := errs.(*Multi)
+	require.True(t, ok)
+	assert.Equal(t, 4, m.Len())
+	assert.Equal(t, "4 errors occurred: 1) EOF; 2) invalid name; 3) unexpected EOF; 4) sentinel", errs.Error())
+	assert.Equal(t, "EOF", Append(nil, io.EOF).Error())
+
+	assert.True(t, errors.Is(errs, io.ErrUnexpectedEOF))
+	assert.True(t, Is(errs, errSentinel))
+	var custom *CustomError
+	assert.True(t, As(errs, &custom))
+
+	verbose := fmt.Sprintf("%+v", errs)
+	assert.True(t, strings.HasPrefix(verbose, "4 errors occurred:\n[1] EOF\n"), verbose)
+	assert.Contains(t, verbose, "\n[4] sentinel\n")
+	assert.Contains(t, verbose, "xerr.TestAppend\n\t")
+}
+
+func TestFilter(t *testing.T) {
+	errs := Append(Custom("a"), io.EOF, Custom("b"))
+
+	filtered := WithoutCustom(errs)
+	require.NotNil(t, filtered)
+	assert.Equal(t, 1, filtered.(*Multi).Len())
+	assert.True(t, Is(filtered, io.EOF))
+
+	assert.Nil(t, WithoutCustom(Append(Custom("a"), Custom("b"))))
+	assert.Nil(t, WithoutCustom(Custom("a")))
+	assert.Equal(t, io.EOF, WithoutCustom(io.EOF))
+	assert.Nil(t, Filter(nil, func(error) bool { return true }))
+
+	// wrap 过的 Multi 同样过滤
+	wrapped := WithMessage(errs, "batch")
+	filtered = WithoutCustom(wrapped)
+	require.NotNil(t, filtered)
+	assert.Equal(t, 1, filtered.(*Multi).Len())
+	assert.Nil(t, WithoutCustom(WithStack(Append(Custom("a"), Custom("b")))))
+	all := WithMessage(Append(io.EOF, io.ErrClosedPipe), "batch")
+	assert.Equal(t, all, WithoutCustom(all))
+
+	// 返回值保留业务错误
+	custom := Append(Custom("a"), Custom("b"))
+	reported := ReportError(context.Background(), custom)
+	require.Error(t, reported)
+	assert.Equal(t, custom.Error(), Cause(reported).Error())
+	reported = ReportError(context.Background(), errs)
+	require.Error(t, reported)
+	assert.Equal(t, 3, Cause(reported).(*Multi).Len())
+}
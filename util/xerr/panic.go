@@ -0,0 +1,99 @@
+package xerr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/zhaolion/gostack/util/log"
+)
+
+// PanicError error converted from a recovered panic value
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+func (e *PanicError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "panic: %+v", e.Value)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+// FromPanic converts a recovered panic value into an error,
+// with the stack trace of the panicking function instead of the recovering one.
+// Call it in the deferred function, returns nil if r is nil.
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			err = xerr.FromPanic(r)
+//		}
+//	}()
+func FromPanic(r interface{}) error {
+	if r == nil {
+		return nil
+	}
+	return &withStack{&PanicError{Value: r}, panicStack()}
+}
+
+// maxRecoverDepth extra frames captured to find runtime.gopanic below deep recovery helpers
+const maxRecoverDepth = 64
+
+// panicStack captures the stack starting from the frame which panicked,
+// frames of recovery (deferred functions and runtime.gopanic) are dropped.
+// The stack of the FromPanic caller is returned if runtime.gopanic is not found.
+func panicStack() *stack {
+	if !StackEnabled() {
+		return emptyStack
+	}
+
+	depth := int(atomic.LoadInt32(&stackDepth))
+	pcs := make([]uintptr, depth+maxRecoverDepth)
+	// skip runtime.Callers, panicStack and FromPanic
+	pcs = pcs[:runtime.Callers(3, pcs)]
+	for i, pc := range pcs {
+		if Frame(pc).name() == "runtime.gopanic" {
+			pcs = pcs[i+1:]
+			break
+		}
+	}
+	if len(pcs) > depth {
+		pcs = pcs[:depth]
+	}
+	st := stack(pcs)
+	return &st
+}
+
+// ReportPanic logs err converted from a panic with fields of ctx (see log.Ctx),
+// and delivers it to reporters
+func ReportPanic(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	log.Ctx(ctx).WithFields(FieldsOf(err)).WithError(err).Error("panic happened")
+	report(err, true)
+}
@@ -0,0 +1,73 @@
+package xerr
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func panicking(v interface{}) {
+	panic(v)
+}
+
+func recovered(v interface{}) (err error) {
+	defer func() {
+		err = FromPanic(recover())
+	}()
+	panicking(v)
+	return nil
+}
+
+func TestFromPanic(t *testing.T) {
+	assert.Nil(t, FromPanic(nil))
+
+	err := recovered("boom")
+	assert.Equal(t, "panic: boom", err.Error())
+	var p *PanicError
+	require.True(t, As(err, &p))
+	assert.Equal(t, "boom", p.Value)
+
+	// stack starts from the panicking function
+	st := err.(stackTracer).StackTrace()
+	require.NotEmpty(t, st)
+	assert.True(t, strings.HasSuffix(st[0].name(), ".panicking"), st[0].name())
+
+	err = recovered(Wrap(io.EOF, "read"))
+	assert.Equal(t, "panic: read: EOF", err.Error())
+	assert.True(t, Is(err, io.EOF))
+}
+
+// fromPanicVia converts r by FromPanic after depth nested calls, like recovery helpers do
+func fromPanicVia(depth int, r interface{}) error {
+	if depth == 0 {
+		return FromPanic(r)
+	}
+	return fromPanicVia(depth-1, r)
+}
+
+func recoveredVia(depth int, v interface{}) (err error) {
+	defer func() {
+		err = fromPanicVia(depth, recover())
+	}()
+	panicking(v)
+	return nil
+}
+
+func TestFromPanicDeepRecover(t *testing.T) {
+	defer SetStackDepth(0)
+	SetStackDepth(4)
+
+	// runtime.gopanic 超出 stack depth 时仍然从 panic 的函数开始
+	st := recoveredVia(10, "boom").(stackTracer).StackTrace()
+	require.NotEmpty(t, st)
+	assert.True(t, strings.HasSuffix(st[0].name(), ".panicking"), st[0].name())
+	assert.LessOrEqual(t, len(st), 4)
+
+	// 不在 panic 中时使用未裁剪的堆栈
+	st = FromPanic("boom").(stackTracer).StackTrace()
+	require.NotEmpty(t, st)
+	assert.True(t, strings.HasSuffix(st[0].name(), ".TestFromPanicDeepRecover"), st[0].name())
+}
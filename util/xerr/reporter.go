@@ -0,0 +1,227 @@
+package xerr
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zhaolion/gostack/util/log"
+)
+
+// Report is delivered to reporters by ReportError, NoticePanic and ReportPanic
+type Report struct {
+	Time        time.Time
+	Err         error
+	Panic       bool
+	Fingerprint string
+	Fields      log.Fields
+}
+
+// Reporter delivers reports to an alerting system, e.g. Sentry, NewRelic or a webhook
+type Reporter interface {
+	Report(ctx context.Context, r *Report) error
+}
+
+// ReporterFunc adapts a function to Reporter
+type ReporterFunc func(ctx context.Context, r *Report) error
+
+// Report calls f(ctx, r)
+func (f ReporterFunc) Report(ctx context.Context, r *Report) error {
+	return f(ctx, r)
+}
+
+// ReporterOption options for RegisterReporter
+type ReporterOption func(*reporterOptions)
+
+type reporterOptions struct {
+	sampleRate  float64
+	dedupWindow time.Duration
+	bufferSize  int
+	timeout     time.Duration
+}
+
+// WithSampleRate only delivers a rate (0, 1] of errors, panics are always delivered
+func WithSampleRate(rate float64) ReporterOption {
+	return func(o *reporterOptions) {
+		o.sampleRate = rate
+	}
+}
+
+// WithDedup drops reports with the same fingerprint within window
+func WithDedup(window time.Duration) ReporterOption {
+	return func(o *reporterOptions) {
+		o.dedupWindow = window
+	}
+}
+
+// WithBufferSize size of the async queue, reports are dropped when the queue is full
+func WithBufferSize(size int) ReporterOption {
+	return func(o *reporterOptions) {
+		o.bufferSize = size
+	}
+}
+
+// WithReportTimeout timeout of a single delivery
+func WithReportTimeout(timeout time.Duration) ReporterOption {
+	return func(o *reporterOptions) {
+		o.timeout = timeout
+	}
+}
+
+var reporters struct {
+	mu    sync.RWMutex
+	sinks []*sink
+}
+
+// RegisterReporter adds a reporter, reports are delivered asynchronously,
+// call FlushReporters before exit (cleanup.Run does it).
+func RegisterReporter(reporter Reporter, opts ...ReporterOption) {
+	o := reporterOptions{
+		sampleRate: 1,
+		bufferSize: 256,
+		timeout:    10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s := &sink{
+		reporter: reporter,
+		opts:     o,
+		queue:    make(chan *Report, o.bufferSize),
+		seen:     make(map[string]time.Time),
+	}
+	go s.run()
+
+	reporters.mu.Lock()
+	defer reporters.mu.Unlock()
+	reporters.sinks = append(reporters.sinks, s)
+}
+
+// FlushReporters waits until queued reports are delivered or timeout,
+// returns false on timeout.
+func FlushReporters(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	// 不持有锁等待, 避免阻塞 RegisterReporter
+	reporters.mu.RLock()
+	sinks := make([]*sink, len(reporters.sinks))
+	copy(sinks, reporters.sinks)
+	reporters.mu.RUnlock()
+
+	for _, s := range sinks {
+		for atomic.LoadInt64(&s.pending) > 0 {
+			if time.Now().After(deadline) {
+				return false
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	return true
+}
+
+// ResetReporters flushes and removes all reporters, mostly for testing
+func ResetReporters() {
+	FlushReporters(time.Second)
+
+	reporters.mu.Lock()
+	defer reporters.mu.Unlock()
+	for _, s := range reporters.sinks {
+		close(s.queue)
+	}
+	reporters.sinks = nil
+}
+
+// report delivers err to every registered reporter
+func report(err error, isPanic bool) {
+	reporters.mu.RLock()
+	defer reporters.mu.RUnlock()
+	if len(reporters.sinks) == 0 {
+		return
+	}
+
+	r := &Report{
+		Time:        time.Now(),
+		Err:         err,
+		Panic:       isPanic,
+		Fingerprint: Fingerprint(err),
+		Fields:      FieldsOf(err),
+	}
+	for _, s := range reporters.sinks {
+		s.deliver(r)
+	}
+}
+
+type sink struct {
+	// atomic counters first for 64-bit alignment
+	pending int64
+	dropped int64
+
+	reporter Reporter
+	opts     reporterOptions
+	queue    chan *Report
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func (s *sink) deliver(r *Report) {
+	if !r.Panic && s.opts.sampleRate < 1 && rand.Float64() >= s.opts.sampleRate {
+		return
+	}
+	if s.duplicated(r) {
+		return
+	}
+
+	atomic.AddInt64(&s.pending, 1)
+	select {
+	case s.queue <- r:
+	default:
+		atomic.AddInt64(&s.pending, -1)
+		if n := atomic.AddInt64(&s.dropped, 1); n == 1 || n%100 == 0 {
+			log.Warnf("xerr: reporter queue is full, %d reports dropped", n)
+		}
+	}
+}
+
+func (s *sink) duplicated(r *Report) bool {
+	if s.opts.dedupWindow <= 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.seen[r.Fingerprint]; ok && r.Time.Sub(last) < s.opts.dedupWindow {
+		return true
+	}
+	// 避免 fingerprint 无限增长
+	if len(s.seen) >= 10000 {
+		s.seen = make(map[string]time.Time)
+	}
+	s.seen[r.Fingerprint] = r.Time
+	return false
+}
+
+func (s *sink) run() {
+	for r := range s.queue {
+		s.send(r)
+		atomic.AddInt64(&s.pending, -1)
+	}
+}
+
+func (s *sink) send(r *Report) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Errorf("xerr: reporter panic: %v", rec)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.timeout)
+	defer cancel()
+	if err := s.reporter.Report(ctx, r); err != nil {
+		// 不能走 ReportError, 避免循环上报
+		log.WithError(err).Warn("xerr: report error failed")
+	}
+}
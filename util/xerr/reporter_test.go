@@ -0,0 +1,138 @@
+package xerr
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memReporter struct {
+	mu      sync.Mutex
+	reports []*Report
+}
+
+func (m *memReporter) Report(ctx context.Context, r *Report) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reports = append(m.reports, r)
+	return nil
+}
+
+func (m *memReporter) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.reports)
+}
+
+func TestReporter(t *testing.T) {
+	defer ResetReporters()
+
+	all := &memReporter{}
+	dedup := &memReporter{}
+	none := &memReporter{}
+	RegisterReporter(all)
+	RegisterReporter(dedup, WithDedup(time.Minute))
+	RegisterReporter(none, WithSampleRate(0.0000001))
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_ = ReportError(ctx, New("db down"))
+	}
+	_ = ReportError(ctx, Custom("biz error"))
+	func() {
+		defer NoticePanic(ctx)
+		panic("boom")
+	}()
+
+	require.True(t, FlushReporters(time.Second))
+	assert.Equal(t, 4, all.Len())
+	assert.Equal(t, 2, dedup.Len())
+	assert.Equal(t, 1, none.Len(), "panics are never sampled")
+	assert.True(t, none.reports[0].Panic)
+	assert.Equal(t, all.reports[0].Fingerprint, all.reports[1].Fingerprint)
+	assert.NotEqual(t, all.reports[0].Fingerprint, all.reports[3].Fingerprint)
+}
+
+func TestReporterQueueFull(t *testing.T) {
+	defer ResetReporters()
+
+	block := make(chan struct{})
+	RegisterReporter(ReporterFunc(func(ctx context.Context, r *Report) error {
+		<-block
+		return nil
+	}), WithBufferSize(1))
+
+	for i := 0; i < 5; i++ {
+		_ = ReportError(context.Background(), io.EOF)
+	}
+	assert.False(t, FlushReporters(10*time.Millisecond))
+
+	// 等待中的 FlushReporters 不阻塞 RegisterReporter
+	flushed := make(chan bool)
+	go func() { flushed <- FlushReporters(time.Second) }()
+	time.Sleep(10 * time.Millisecond)
+	registered := make(chan struct{})
+	go func() {
+		RegisterReporter(&memReporter{})
+		close(registered)
+	}()
+	select {
+	case <-registered:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("RegisterReporter blocked by FlushReporters")
+	}
+	close(block)
+	assert.True(t, <-flushed)
+	assert.True(t, FlushReporters(time.Second))
+}
+
+func TestWebhookReporter(t *testing.T) {
+	defer ResetReporters()
+
+	var (
+		mu       sync.Mutex
+		payloads []map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+
+		payload := map[string]interface{}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	webhook := NewWebhookReporter(server.URL)
+	webhook.Header.Set("X-Token", "secret")
+	RegisterReporter(webhook)
+
+	_ = ReportError(context.Background(), WithField(io.EOF, "user_id", 1), "load user")
+	require.True(t, FlushReporters(time.Second))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, payloads, 1)
+	assert.Equal(t, false, payloads[0]["panic"])
+	assert.NotEmpty(t, payloads[0]["fingerprint"])
+	errField := payloads[0]["error"].(map[string]interface{})
+	assert.Equal(t, "load user: EOF", errField["message"])
+	assert.Equal(t, map[string]interface{}{"user_id": float64(1)}, errField["attrs"])
+
+	assert.Error(t, NewWebhookReporter(server.URL+"/fail").Report(context.Background(), &Report{Err: io.EOF}))
+	assert.Error(t, NewWebhookReporter("http://127.0.0.1:0").Report(context.Background(), &Report{Err: io.EOF}))
+}
@@ -0,0 +1,149 @@
+package xerr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"syscall"
+
+	"github.com/zhaolion/gostack/util/log"
+)
+
+// Retryable marks err as worth retrying, overrides the automatic classification.
+// If err is nil, Retryable returns nil.
+func Retryable(err error) error {
+	return withRetry(err, true)
+}
+
+// Permanent marks err as not worth retrying, overrides the automatic classification.
+// If err is nil, Permanent returns nil.
+func Permanent(err error) error {
+	return withRetry(err, false)
+}
+
+func withRetry(err error, retryable bool) error {
+	if err == nil {
+		return nil
+	}
+	return &withRetryable{cause: err, retryable: retryable}
+}
+
+// RetryClassifier classifies err, ok is false if err is unknown to the classifier
+type RetryClassifier func(err error) (retryable bool, ok bool)
+
+var retryClassifiers struct {
+	mu  sync.RWMutex
+	fns []RetryClassifier
+}
+
+// RegisterRetryClassifier adds a classifier consulted by IsRetryable,
+// e.g. for errors of a RPC framework. Classifiers registered later run first.
+func RegisterRetryClassifier(fn RetryClassifier) {
+	retryClassifiers.mu.Lock()
+	defer retryClassifiers.mu.Unlock()
+	retryClassifiers.fns = append([]RetryClassifier{fn}, retryClassifiers.fns...)
+}
+
+// IsRetryable reports whether err is worth retrying:
+//  1. marked by Retryable / Permanent
+//  2. registered classifiers
+//  3. context.DeadlineExceeded, timeout/temporary errors (net.Error), connection
+//     reset/refused, database deadlock/lock timeout/connection errors (see ClassifyDBError)
+//  4. categories Unavailable, DeadlineExceeded and ResourceExhausted
+//
+// context.Canceled and other errors are not retryable. io.ErrUnexpectedEOF is not
+// retryable either, it also comes from truncated payloads and decoding, opt in by
+// RegisterRetryClassifier if it means a broken connection.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	type retryer interface {
+		Retryable() bool
+	}
+	var r retryer
+	if As(err, &r) {
+		return r.Retryable()
+	}
+
+	retryClassifiers.mu.RLock()
+	fns := retryClassifiers.fns
+	retryClassifiers.mu.RUnlock()
+	for _, fn := range fns {
+		if retryable, ok := fn(err); ok {
+			return retryable
+		}
+	}
+
+	if Is(err, context.Canceled) {
+		return false
+	}
+	if isTransient(err) {
+		return true
+	}
+
+	switch CategoryOf(err) {
+	case CategoryUnavailable, CategoryDeadlineExceeded, CategoryResourceExhausted:
+		return true
+	}
+	return false
+}
+
+var transientErrors = []error{
+	context.DeadlineExceeded,
+	syscall.ECONNRESET,
+	syscall.ECONNREFUSED,
+	syscall.ECONNABORTED,
+	syscall.EPIPE,
+	syscall.ETIMEDOUT,
+}
+
+func isTransient(err error) bool {
+	for _, target := range transientErrors {
+		if Is(err, target) {
+			return true
+		}
+	}
+
+	return walk(err, func(e error) bool {
+		if t, ok := e.(interface{ Timeout() bool }); ok && t.Timeout() {
+			return true
+		}
+		if t, ok := e.(interface{ Temporary() bool }); ok && t.Temporary() {
+			return true
+		}
+		switch dbErrorKind(e) {
+		case DBErrDeadlock, DBErrLockTimeout, DBErrConnection:
+			return true
+		}
+		return false
+	})
+}
+
+type withRetryable struct {
+	cause     error
+	retryable bool
+}
+
+func (w *withRetryable) Error() string      { return w.cause.Error() }
+func (w *withRetryable) Cause() error       { return w.cause }
+func (w *withRetryable) Unwrap() error      { return w.cause }
+func (w *withRetryable) Retryable() bool    { return w.retryable }
+func (w *withRetryable) Fields() log.Fields { return log.Fields{"retryable": w.retryable} }
+
+func (w *withRetryable) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", w.cause)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
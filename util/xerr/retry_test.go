@@ -0,0 +1,69 @@
+package xerr
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "pq: " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestIsRetryable(t *testing.T) {
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, false},
+		{New("boom"), false},
+		{context.Canceled, false},
+		{Wrap(context.DeadlineExceeded, "query"), true},
+		{fmt.Errorf("query: %w", driver.ErrBadConn), true},
+		{Wrap(netErr), true},
+		{&net.DNSError{Err: "timeout", IsTimeout: true}, true},
+		{sqlStateError("40P01"), true},
+		{sqlStateError("23505"), false},
+		{Coded(CategoryUnavailable, "UPSTREAM_DOWN", "upstream down"), true},
+		{Coded(CategoryNotFound, "ORDER_NOT_FOUND", "order not found"), false},
+		{Retryable(io.EOF), true},
+		{Wrap(Retryable(io.EOF), "read"), true},
+		{Permanent(Wrap(context.DeadlineExceeded)), false},
+		{Retryable(Permanent(io.EOF)), true},
+	} {
+		assert.Equal(t, tt.want, IsRetryable(tt.err), "%v", tt.err)
+	}
+
+	assert.Nil(t, Retryable(nil))
+	assert.Nil(t, Permanent(nil))
+	assert.Equal(t, "EOF", Retryable(io.EOF).Error())
+	assert.Equal(t, io.EOF, Cause(Retryable(io.EOF)))
+	assert.Equal(t, true, FieldsOf(Retryable(io.EOF))["retryable"])
+}
+
+func TestRegisterRetryClassifier(t *testing.T) {
+	defer func(fns []RetryClassifier) { retryClassifiers.fns = fns }(retryClassifiers.fns)
+
+	// 截断的响应或解码错误重试也不会成功, 默认不重试, 由调用方按需开启
+	assert.False(t, IsRetryable(Wrap(io.ErrUnexpectedEOF)))
+	RegisterRetryClassifier(func(err error) (bool, bool) {
+		if Is(err, io.ErrUnexpectedEOF) {
+			return true, true
+		}
+		return false, false
+	})
+	assert.True(t, IsRetryable(Wrap(io.ErrUnexpectedEOF)))
+	assert.False(t, IsRetryable(Permanent(io.ErrUnexpectedEOF)))
+	assert.True(t, IsRetryable(driver.ErrBadConn))
+}
@@ -6,6 +6,8 @@ import (
 	"path"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Frame represents a program counter inside a stack frame.
@@ -18,23 +20,45 @@ func (f Frame) pc() uintptr { return uintptr(f) - 1 }
 // file returns the full path to the file that contains the
 // function for this Frame's pc.
 func (f Frame) file() string {
-	fn := runtime.FuncForPC(f.pc())
-	if fn == nil {
-		return "unknown"
-	}
-	file, _ := fn.FileLine(f.pc())
-	return file
+	return f.symbol().file
 }
 
 // line returns the line number of source code of the
 // function for this Frame's pc.
 func (f Frame) line() int {
-	fn := runtime.FuncForPC(f.pc())
-	if fn == nil {
-		return 0
+	return f.symbol().line
+}
+
+// name returns the name of this function, if known.
+func (f Frame) name() string {
+	return f.symbol().name
+}
+
+// frameSymbol symbolized frame, fn is nil if unknown
+type frameSymbol struct {
+	fn   *runtime.Func
+	name string
+	file string
+	line int
+}
+
+// symbols caches symbolized frames by pc, frames are only symbolized
+// when a stack trace is printed or serialized, not when it is captured.
+var symbols sync.Map
+
+func (f Frame) symbol() *frameSymbol {
+	if sym, ok := symbols.Load(f); ok {
+		return sym.(*frameSymbol)
 	}
-	_, line := fn.FileLine(f.pc())
-	return line
+
+	sym := &frameSymbol{name: "unknown", file: "unknown"}
+	if fn := runtime.FuncForPC(f.pc()); fn != nil {
+		sym.fn = fn
+		sym.name = fn.Name()
+		sym.file, sym.line = fn.FileLine(f.pc())
+	}
+	symbols.Store(f, sym)
+	return sym
 }
 
 // Format formats the frame according to the fmt.Formatter interface.
@@ -54,13 +78,11 @@ func (f Frame) Format(s fmt.State, verb rune) {
 	case 's':
 		switch {
 		case s.Flag('+'):
-			pc := f.pc()
-			fn := runtime.FuncForPC(pc)
-			if fn == nil {
+			sym := f.symbol()
+			if sym.fn == nil {
 				io.WriteString(s, "unknown")
 			} else {
-				file, _ := fn.FileLine(pc)
-				fmt.Fprintf(s, "%s\n\t%s", fn.Name(), file)
+				fmt.Fprintf(s, "%s\n\t%s", sym.name, sym.file)
 			}
 		default:
 			io.WriteString(s, path.Base(f.file()))
@@ -68,8 +90,7 @@ func (f Frame) Format(s fmt.State, verb rune) {
 	case 'd':
 		fmt.Fprintf(s, "%d", f.line())
 	case 'n':
-		name := runtime.FuncForPC(f.pc()).Name()
-		io.WriteString(s, funcname(name))
+		io.WriteString(s, funcname(f.name()))
 	case 'v':
 		f.Format(s, 's')
 		io.WriteString(s, ":")
@@ -114,41 +135,135 @@ func (s *stack) Format(st fmt.State, verb rune) {
 	case 'v':
 		switch {
 		case st.Flag('+'):
-			for _, pc := range *s {
-				f := Frame(pc)
+			for _, f := range s.StackTrace() {
 				fmt.Fprintf(st, "\n%+v", f)
 			}
 		}
 	}
 }
 
+// StackTrace frames of the stack, frames matching SkipFramePrefixes() are dropped
 func (s *stack) StackTrace() StackTrace {
-	f := make([]Frame, len(*s))
-	for i := 0; i < len(f); i++ {
-		f[i] = Frame((*s)[i])
+	f := make([]Frame, 0, len(*s))
+	for _, pc := range *s {
+		if frame := Frame(pc); !skipFrame(frame.name()) {
+			f = append(f, frame)
+		}
 	}
 	return f
 }
 
+// DefaultStackDepth default max number of frames captured
+const DefaultStackDepth = 32
+
+// DefaultSkipFramePrefixes frames dropped from stack traces by default,
+// e.g. runtime.goexit, runtime.gopanic and testing.tRunner
+var DefaultSkipFramePrefixes = []string{"runtime.", "testing."}
+
+var skipFramePrefixes atomic.Value
+
+func init() {
+	skipFramePrefixes.Store(DefaultSkipFramePrefixes)
+}
+
+// SetSkipFramePrefixes sets prefixes of functions whose frames are dropped from stack traces,
+// no prefixes resets them to DefaultSkipFramePrefixes
+func SetSkipFramePrefixes(prefixes ...string) {
+	if len(prefixes) == 0 {
+		prefixes = DefaultSkipFramePrefixes
+	}
+	skipFramePrefixes.Store(append([]string(nil), prefixes...))
+}
+
+// SkipFramePrefixes return prefixes of functions whose frames are dropped from stack traces
+func SkipFramePrefixes() []string {
+	return append([]string(nil), skipFramePrefixes.Load().([]string)...)
+}
+
+var (
+	stackDepth    int32 = DefaultStackDepth
+	stackDisabled int32
+)
+
+// SetStackDepth sets max number of frames captured, n <= 0 resets it to DefaultStackDepth
+func SetStackDepth(n int) {
+	if n <= 0 {
+		n = DefaultStackDepth
+	}
+	atomic.StoreInt32(&stackDepth, int32(n))
+}
+
+// DisableStack disables (or enables) stack capture globally, e.g. in hot paths
+// where errors are expected and handled. Errors still wrap as usual, with empty stack traces.
+func DisableStack(disabled bool) {
+	var v int32
+	if disabled {
+		v = 1
+	}
+	atomic.StoreInt32(&stackDisabled, v)
+}
+
+// StackEnabled reports whether stack capture is enabled
+func StackEnabled() bool {
+	return atomic.LoadInt32(&stackDisabled) == 0
+}
+
+func skipFrame(name string) bool {
+	for _, prefix := range skipFramePrefixes.Load().([]string) {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// emptyStack is shared by errors created when stack capture is disabled
+var emptyStack = &stack{}
+
 func callers() *stack {
 	return callersWithSkip(4)
 }
 
+// callersWithSkip captures program counters only, symbolization is deferred to printing
 func callersWithSkip(skip int) *stack {
-	const depth = 32
-	var pcs [depth]uintptr
-	n := runtime.Callers(skip, pcs[:])
+	if !StackEnabled() {
+		return emptyStack
+	}
+
+	pcs := make([]uintptr, atomic.LoadInt32(&stackDepth))
+	n := runtime.Callers(skip, pcs)
 	var st stack = pcs[0:n]
 	return &st
 }
 
+// callersWithErr reuses the stack captured by err's chain, captures a new one if none
 func callersWithErr(err error) *stack {
-	if stackErr, ok := err.(interface{ Stack() *stack }); ok {
-		return stackErr.Stack()
+	if st := stackOf(err); st != nil {
+		return st
 	}
 	return callersWithSkip(4)
 }
 
+// stackOf return the stack of the first error capturing stack in the chain of err,
+// members of Multi are not checked, they have their own stacks
+func stackOf(err error) *stack {
+	for err != nil {
+		switch e := err.(type) {
+		case *withStack:
+			return e.stack
+		case *fundamental:
+			return e.stack
+		case interface{ Cause() error }:
+			err = e.Cause()
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
 // funcname removes the path prefix component of a function's name reported by func.Name().
 func funcname(name string) string {
 	i := strings.LastIndex(name, "/")
@@ -0,0 +1,68 @@
+package xerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func BenchmarkStdNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = errors.New("test error")
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = New("test error")
+	}
+}
+
+func BenchmarkNewDepth8(b *testing.B) {
+	SetStackDepth(8)
+	defer SetStackDepth(0)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = New("test error")
+	}
+}
+
+func BenchmarkNewWithoutStack(b *testing.B) {
+	DisableStack(true)
+	defer DisableStack(false)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = New("test error")
+	}
+}
+
+func BenchmarkWrap(b *testing.B) {
+	err := errors.New("test error")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Wrap(err, "wrap")
+	}
+}
+
+func BenchmarkStdWrap(b *testing.B) {
+	err := errors.New("test error")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Errorf("wrap: %w", err)
+	}
+}
+
+func BenchmarkFormatStack(b *testing.B) {
+	err := New("test error")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("%+v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -35,5 +36,60 @@ func wrapErr(err error) error {
 
 func funcStack(s *stack) string {
 	frame, _ := runtime.CallersFrames(*s).Next()
-	return fmt.Sprintf("pkg.xerr.%s", funcname(frame.Function))
+	// same format as runtimeutil.CallerFuncName
+	return strings.Join(strings.Split(frame.Function, "/")[2:], ".")
+}
+
+func TestStackControls(t *testing.T) {
+	defer SetStackDepth(0)
+	defer DisableStack(false)
+
+	err := New("test error")
+	st := err.(stackTracer).StackTrace()
+	require.NotEmpty(t, st)
+	assert.Equal(t, runtimeutil.CallerFuncName(0), strings.Join(strings.Split(st[0].name(), "/")[2:], "."))
+	for _, f := range st {
+		assert.False(t, strings.HasPrefix(f.name(), "testing."), f.name())
+		assert.False(t, strings.HasPrefix(f.name(), "runtime."), f.name())
+	}
+
+	SetStackDepth(1)
+	assert.Len(t, *New("test error").(*fundamental).stack, 1)
+
+	DisableStack(true)
+	assert.False(t, StackEnabled())
+	err = Wrap(New("test error"), "wrap")
+	assert.Equal(t, "wrap: test error", err.Error())
+	assert.Empty(t, err.(stackTracer).StackTrace())
+	assert.Equal(t, "test error", fmt.Sprintf("%+v", New("test error")))
+}
+
+func TestStackReuse(t *testing.T) {
+	inner := WithStack(errors.New("test error"))
+	innerStack := inner.(*withStack).stack
+
+	err := WithStack(WithMessage(inner, "load"))
+	assert.Same(t, innerStack, err.(*withStack).stack)
+	err = Wrap(WithMessage(inner, "load"), "save")
+	assert.Same(t, innerStack, err.(*withStack).stack)
+
+	verbose := fmt.Sprintf("%+v", err)
+	assert.Equal(t, 1, strings.Count(verbose, "xerr.TestStackReuse\n"), verbose)
+
+	// Multi 成员的堆栈不复用
+	err = WithStack(Append(inner))
+	assert.NotSame(t, innerStack, err.(*withStack).stack)
+}
+
+func TestSetSkipFramePrefixes(t *testing.T) {
+	defer SetSkipFramePrefixes()
+
+	SetSkipFramePrefixes("github.com/zhaolion/gostack/util/xerr.")
+	assert.Equal(t, []string{"github.com/zhaolion/gostack/util/xerr."}, SkipFramePrefixes())
+	for _, f := range New("test error").(stackTracer).StackTrace() {
+		assert.False(t, strings.HasPrefix(f.name(), "github.com/zhaolion/gostack/util/xerr."), f.name())
+	}
+
+	SetSkipFramePrefixes()
+	assert.Equal(t, DefaultSkipFramePrefixes, SkipFramePrefixes())
 }
@@ -0,0 +1,85 @@
+package xerr
+
+import (
+	"reflect"
+)
+
+// Is reports whether any error in err's tree matches target, same as errors.Is.
+// The tree is walked through Cause() error, Unwrap() error and
+// Unwrap() []error (Go 1.20 multi errors), so it works for xerr wrappers,
+// fmt.Errorf("%w") and other causer implementations alike.
+func Is(err, target error) bool {
+	if err == nil || target == nil {
+		return err == target
+	}
+
+	isComparable := reflect.TypeOf(target).Comparable()
+	return walk(err, func(e error) bool {
+		if isComparable && e == target {
+			return true
+		}
+		if x, ok := e.(interface{ Is(error) bool }); ok && x.Is(target) {
+			return true
+		}
+		return false
+	})
+}
+
+// As finds the first error in err's tree that matches target, and if one is found,
+// sets target to that error value and returns true, same as errors.As.
+// target must be a non-nil pointer to an interface or a type implementing error.
+func As(err error, target interface{}) bool {
+	if err == nil {
+		return false
+	}
+	if target == nil {
+		panic("xerr: target cannot be nil")
+	}
+
+	val := reflect.ValueOf(target)
+	typ := val.Type()
+	if typ.Kind() != reflect.Ptr || val.IsNil() {
+		panic("xerr: target must be a non-nil pointer")
+	}
+	targetType := typ.Elem()
+	if targetType.Kind() != reflect.Interface && !targetType.Implements(errorType) {
+		panic("xerr: *target must be interface or implement error")
+	}
+
+	return walk(err, func(e error) bool {
+		if reflect.TypeOf(e).AssignableTo(targetType) {
+			val.Elem().Set(reflect.ValueOf(e))
+			return true
+		}
+		if x, ok := e.(interface{ As(interface{}) bool }); ok && x.As(target) {
+			return true
+		}
+		return false
+	})
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// walk calls fn for every error in err's tree (depth-first) until fn returns true
+func walk(err error, fn func(error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if fn(err) {
+		return true
+	}
+
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, child := range e.Unwrap() {
+			if walk(child, fn) {
+				return true
+			}
+		}
+	case interface{ Cause() error }:
+		return walk(e.Cause(), fn)
+	case interface{ Unwrap() error }:
+		return walk(e.Unwrap(), fn)
+	}
+	return false
+}
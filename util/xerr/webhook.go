@@ -0,0 +1,81 @@
+package xerr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+)
+
+// WebhookReporter posts reports as JSON to URL:
+//
+//	{"time": "...", "host": "...", "panic": false, "fingerprint": "...", "error": {...}}
+//
+// error is the JSONError of the reported error.
+type WebhookReporter struct {
+	URL    string
+	Client *http.Client
+	Header http.Header
+}
+
+// NewWebhookReporter return a webhook reporter posting to url
+func NewWebhookReporter(url string) *WebhookReporter {
+	return &WebhookReporter{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+		Header: make(http.Header),
+	}
+}
+
+type webhookPayload struct {
+	Time        time.Time  `json:"time"`
+	Host        string     `json:"host,omitempty"`
+	Panic       bool       `json:"panic"`
+	Fingerprint string     `json:"fingerprint"`
+	Error       *JSONError `json:"error"`
+}
+
+// Report posts r to the webhook
+func (w *WebhookReporter) Report(ctx context.Context, r *Report) error {
+	host, _ := os.Hostname()
+	data, err := json.Marshal(&webhookPayload{
+		Time:        r.Time,
+		Host:        host,
+		Panic:       r.Panic,
+		Fingerprint: r.Fingerprint,
+		Error:       ToJSON(r.Err),
+	})
+	if err != nil {
+		return WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(data))
+	if err != nil {
+		return WithStack(err)
+	}
+	for k, vv := range w.Header {
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return WithStack(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
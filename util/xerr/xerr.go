@@ -2,7 +2,6 @@ package xerr
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/zhaolion/gostack/util/log"
@@ -11,7 +10,7 @@ import (
 
 // 自定义处理过的函数放置在这里
 
-// NoticePanic records panic
+// NoticePanic records panic, must be called directly by defer
 func NoticePanic(ctx context.Context, names ...string) {
 	var name string
 	if len(names) == 0 {
@@ -20,20 +19,12 @@ func NoticePanic(ctx context.Context, names ...string) {
 		name = names[0]
 	}
 	if r := recover(); r != nil {
-		var e error
-		if err, ok := r.(error); ok {
-			e = fmt.Errorf("[%s] %+v", name, err)
-		} else if s, ok := r.(string); ok {
-			e = New(s)
-		} else {
-			e = fmt.Errorf("%v", r)
-		}
-
-		log.WithError(e).Error("panic happened")
+		ReportPanic(ctx, WithField(FromPanic(r), "name", name))
 	}
 }
 
-// ReportError 顶层函数需要主动调用这个进行错误日志上报和报警
+// ReportError 顶层函数需要主动调用这个进行错误日志上报和报警,
+// 返回 wrap 了 messages 的 err, 业务错误 (CustomError) 不上报, 但仍然原样返回
 func ReportError(ctx context.Context, err error, messages ...string) error {
 	if err == nil {
 		return nil
@@ -44,16 +35,28 @@ func ReportError(ctx context.Context, err error, messages ...string) error {
 		return nil
 	}
 
-	// 忽略业务自定义错误
-	_, ok := originErr.(*CustomError)
-	if ok {
-		return nil
+	e := Wrap(err, messages...)
+
+	// 忽略业务自定义错误, Multi 只上报非业务错误
+	reported := WithoutCustom(err)
+	if reported == nil {
+		return e
+	}
+	if reported != err {
+		reported = Wrap(reported, messages...)
+	} else {
+		reported = e
 	}
 
-	e := Wrap(err, messages...)
+	// 重复错误只计数, 定期输出汇总 (SetReportSummary)
+	if !observeReport(reported) {
+		return e
+	}
 
 	// 打个错误堆栈日志
-	log.Ctx(ctx).Errorf("%+v", e)
+	log.Ctx(ctx).WithFields(FieldsOf(reported)).Errorf("%+v", reported)
+	// 上报到注册的 Reporter
+	report(reported, false)
 
 	return e
 }
@@ -96,7 +99,7 @@ func WrapWithLog(err error, messages ...string) error {
 		callersWithErr(err),
 	}
 
-	log.Errorf("%+v", err)
+	log.WithFields(FieldsOf(err)).Errorf("%+v", err)
 
 	return err
 }
@@ -131,7 +134,7 @@ func buildMessage(messages ...string) string {
 func StackWithLog(err error) error {
 	err = wrapStack(err, 1)
 	if err != nil {
-		log.Errorf("%+s", err)
+		log.WithFields(FieldsOf(err)).Errorf("%+s", err)
 	}
 
 	return err
@@ -144,15 +147,14 @@ func Stack(err error) error {
 	return wrapStack(err, 1)
 }
 
-// ErrorEqual 判断 err 是否为 errors 某一个错误
+// ErrorEqual 判断 err 是否为 errors 某一个错误, 按 Is 比较, 不再比较错误信息
 func ErrorEqual(err error, errors ...error) bool {
-	root := Cause(err)
-	if root == nil {
+	if err == nil {
 		return false
 	}
 
 	for _, e := range errors {
-		if root.Error() == e.Error() {
+		if Is(err, e) {
 			return true
 		}
 	}